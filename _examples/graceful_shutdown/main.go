@@ -4,9 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
 	"github.com/hossein1376/grape"
 	"github.com/hossein1376/grape/slogger"
@@ -17,36 +15,27 @@ func main() {
 	router := grape.NewRouter()
 	router.Get("/", rootHandler)
 
-	// create an instance of *http.Server; and pass it down to the Serve method.
-	srv := &http.Server{}
-
-	// quit channel listens for the interrupt signal.
-	// failure channel is used to communicate server's startup error.
-	quit, failure := make(chan os.Signal, 1), make(chan error, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	slog.Debug("starting server")
-
-	go func() {
-		slog.Info("starting server on port 8000...")
-		err := router.Serve(":8000", srv) // passing srv instead of nil
-		if err != nil {
-			failure <- err
-			return
-		}
-	}()
-
-	select {
-	case <-quit:
-		// after receiving the signal, gracefully stop the server.
-		if err := srv.Shutdown(context.Background()); err != nil {
-			slog.Error("graceful shutdown failed", slogger.Err("error", err))
-			return
-		}
-		slog.Info("server was gracefully shutdown")
-
-	case err := <-failure:
-		slog.Error("failed to start server", "error", err)
+	slog.Info("starting server on port 8000...")
+	// ServeWithContext blocks until the context is canceled, or an interrupt
+	// signal is received. Then, it stops accepting new connections and waits
+	// for in-flight requests to be served.
+	err := router.ServeWithContext(
+		context.Background(),
+		":8000",
+		grape.WithDrainTimeout(10*time.Second),
+		// wait for goroutines started via grape.Go to finish.
+		grape.WithWaitGoroutines(),
+		grape.WithOnShutdown(func(ctx context.Context) error {
+			// close database pools, flush buffers, etc.
+			slog.Info("running shutdown hook")
+			return nil
+		}),
+	)
+	if err != nil {
+		slog.Error("server failure", slogger.Err("error", err))
+		return
 	}
+	slog.Info("server was gracefully shutdown")
 }
 
 func rootHandler(w http.ResponseWriter, r *http.Request) {
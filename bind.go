@@ -0,0 +1,255 @@
+package grape
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hossein1376/grape/validator"
+)
+
+// bindSources lists the struct tags which bind reads, alongside the function
+// that extracts the values of the given name from the request.
+var bindSources = []struct {
+	tag    string
+	values func(r *http.Request, name string) []string
+}{
+	{
+		tag: "path",
+		values: func(r *http.Request, name string) []string {
+			if v := r.PathValue(name); v != "" {
+				return []string{v}
+			}
+			return nil
+		},
+	},
+	{
+		tag: "query",
+		values: func(r *http.Request, name string) []string {
+			return r.URL.Query()[name]
+		},
+	},
+	{
+		tag: "header",
+		values: func(r *http.Request, name string) []string {
+			return r.Header.Values(name)
+		},
+	},
+	{
+		tag: "cookie",
+		values: func(r *http.Request, name string) []string {
+			var values []string
+			for _, c := range r.CookiesNamed(name) {
+				values = append(values, c.Value)
+			}
+			return values
+		},
+	},
+}
+
+// Bind creates a new instance of T, and fills its fields from the request,
+// according to their struct tags:
+//
+//	type listRequest struct {
+//		ID      int64    `path:"id"`
+//		Page    int      `query:"page"`
+//		Tags    []string `query:"tag"`
+//		Tenant  string   `header:"X-Tenant,required"`
+//		Session *string  `cookie:"session"`
+//	}
+//
+// Path parameters are always required, while the others are only required if
+// they have the `required` option. Fields without a value are left untouched.
+// Slices receive all the values, and pointers are allocated as needed.
+//
+// Builtin types are parsed via [strconv.ParseBool], [ParseInt], [ParseUint] and
+// [ParseFloat]. Types implementing the Parse method described in [Param], or
+// [encoding.TextUnmarshaler], are parsed using those methods instead.
+//
+// All missing and malformed fields are reported together, as a
+// [validator.ValidationError] keyed by their tag name.
+func Bind[T any](r *http.Request) (T, error) {
+	var t T
+	err := bind(r, &t)
+	return t, err
+}
+
+// bind fills the fields of the struct pointed to by dst. Refer to [Bind] for
+// more details.
+func bind(r *http.Request, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: expected pointer to struct, got %T", dst)
+	}
+
+	verrs := make(validator.ValidationError)
+	bindStruct(r, v.Elem(), verrs)
+	if len(verrs) != 0 {
+		return verrs
+	}
+	return nil
+}
+
+func bindStruct(
+	r *http.Request, v reflect.Value, verrs validator.ValidationError,
+) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		// Fields of untagged embedded structs are bound as if they were
+		// declared in the outer struct.
+		if field.Anonymous && field.Tag == "" &&
+			field.Type.Kind() == reflect.Struct {
+			bindStruct(r, v.Field(i), verrs)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		for _, src := range bindSources {
+			tag, ok := field.Tag.Lookup(src.tag)
+			if !ok {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			values := src.values(r, name)
+			if len(values) == 0 {
+				if src.tag == "path" || hasTagOption(opts, "required") {
+					verrs[name] = append(verrs[name], "is required")
+				}
+				continue
+			}
+			if err := setField(v.Field(i), values); err != nil {
+				verrs[name] = append(verrs[name], bindErrMsg(err))
+			}
+		}
+	}
+}
+
+func hasTagOption(opts, option string) bool {
+	return slices.Contains(strings.Split(opts, ","), option)
+}
+
+// bindErrMsg returns a human-readable message describing the parse error.
+func bindErrMsg(err error) string {
+	var numErr *strconv.NumError
+	switch {
+	case errors.Is(err, ErrOverflow):
+		return "value is out of range"
+	case errors.As(err, &numErr):
+		return "invalid value " + strconv.Quote(numErr.Num)
+	default:
+		return err.Error()
+	}
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// setField parses the values into the field. Slices receive all the values,
+// while other types only use the first one.
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !isScalar(field) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, values[0])
+}
+
+// isScalar reports whether the value is parsed from a single string, despite
+// its kind. For example, types implementing [encoding.TextUnmarshaler].
+func isScalar(v reflect.Value) bool {
+	return reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) ||
+		parseMethod(v.Type()).IsValid()
+}
+
+// setValue parses s into v. Types implementing the Parse method, as described
+// in [Param], take precedence over [encoding.TextUnmarshaler], which itself
+// takes precedence over the builtin kinds.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	if m := parseMethod(v.Type()); m.IsValid() {
+		in := []reflect.Value{reflect.Zero(v.Type()), reflect.ValueOf(s)}
+		out := m.Call(in)
+		if err, _ := out[1].Interface().(error); err != nil {
+			return fmt.Errorf("parse: %w", err)
+		}
+		v.Set(out[0])
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	parser, ok := kindParsers[v.Kind()]
+	if !ok {
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	parsed, err := parser(s)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(parsed).Convert(v.Type()))
+	return nil
+}
+
+// parseMethod returns the Parse method expression of the type, if it has the
+// following signature. Otherwise, the returned value is invalid.
+//
+//	func (T) Parse(s string) (T, error)
+func parseMethod(t reflect.Type) reflect.Value {
+	m, ok := t.MethodByName("Parse")
+	if !ok {
+		return reflect.Value{}
+	}
+	mt := m.Type
+	if mt.NumIn() != 2 || mt.In(1).Kind() != reflect.String ||
+		mt.NumOut() != 2 || mt.Out(0) != t ||
+		mt.Out(1) != reflect.TypeFor[error]() {
+		return reflect.Value{}
+	}
+	return m.Func
+}
+
+// kindParsers maps builtin kinds to their parser functions, converting their
+// result to any.
+var kindParsers = map[reflect.Kind]func(string) (any, error){
+	reflect.String:  func(s string) (any, error) { return s, nil },
+	reflect.Bool:    anyParser(strconv.ParseBool),
+	reflect.Int:     anyParser(ParseInt[int]()),
+	reflect.Int8:    anyParser(ParseInt[int8]()),
+	reflect.Int16:   anyParser(ParseInt[int16]()),
+	reflect.Int32:   anyParser(ParseInt[int32]()),
+	reflect.Int64:   anyParser(ParseInt[int64]()),
+	reflect.Uint:    anyParser(ParseUint[uint]()),
+	reflect.Uint8:   anyParser(ParseUint[uint8]()),
+	reflect.Uint16:  anyParser(ParseUint[uint16]()),
+	reflect.Uint32:  anyParser(ParseUint[uint32]()),
+	reflect.Uint64:  anyParser(ParseUint[uint64]()),
+	reflect.Float32: anyParser(ParseFloat[float32]()),
+	reflect.Float64: anyParser(ParseFloat[float64]()),
+}
+
+func anyParser[T any](parser Parser[T]) func(string) (any, error) {
+	return func(s string) (any, error) {
+		return parser(s)
+	}
+}
@@ -0,0 +1,88 @@
+package grape
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hossein1376/grape/validator"
+)
+
+type pagination struct {
+	Page  int    `query:"page"`
+	Limit *uint8 `query:"limit"`
+}
+
+type bindTarget struct {
+	pagination
+	ID      int64      `path:"id"`
+	Tags    []string   `query:"tag"`
+	Since   time.Time  `query:"since"`
+	Custom  customType `query:"custom"`
+	Tenant  string     `header:"X-Tenant,required"`
+	Session string     `cookie:"session"`
+}
+
+func newBindRequest(target string, pattern string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		req = r
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	return req
+}
+
+func TestBind_Success(t *testing.T) {
+	req := newBindRequest(
+		"/items/42?page=2&limit=10&tag=a&tag=b&since=2024-01-02T03:04:05Z&custom=x",
+		"/items/{id}",
+	)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"})
+
+	got, err := Bind[bindTarget](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != 42 || got.Page != 2 || got.Limit == nil || *got.Limit != 10 {
+		t.Fatalf("unexpected numeric fields: %+v", got)
+	}
+	if !slices.Equal(got.Tags, []string{"a", "b"}) {
+		t.Fatalf("unexpected tags: %v", got.Tags)
+	}
+	if got.Since.Year() != 2024 || got.Custom.V != "x" {
+		t.Fatalf("unexpected custom fields: %+v", got)
+	}
+	if got.Tenant != "acme" || got.Session != "s3cr3t" {
+		t.Fatalf("unexpected header or cookie: %+v", got)
+	}
+}
+
+func TestBind_AggregatesErrors(t *testing.T) {
+	req := newBindRequest("/items/abc?page=x&limit=300", "/items/{id}")
+
+	_, err := Bind[bindTarget](req)
+	var verrs validator.ValidationError
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, key := range []string{"id", "page", "limit", "X-Tenant"} {
+		if len(verrs[key]) == 0 {
+			t.Errorf("expected error for %q, got %v", key, verrs)
+		}
+	}
+	if _, ok := verrs["session"]; ok {
+		t.Errorf("optional cookie must not be reported: %v", verrs)
+	}
+}
+
+func TestBind_NonStruct(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := Bind[int](req); err == nil {
+		t.Fatalf("expected error for non-struct type")
+	}
+}
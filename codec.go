@@ -0,0 +1,306 @@
+package grape
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hossein1376/grape/errs"
+)
+
+// Codec encodes and decodes values of a specific media type. Implementations
+// must be safe for concurrent use.
+type Codec interface {
+	// MediaType returns the media type handled by the codec, such as
+	// "application/json".
+	MediaType() string
+	// Marshal returns the encoding of v.
+	Marshal(v any) ([]byte, error)
+	// Decode reads a single encoded value from r, and stores it in v. Errors
+	// are expected to be human-readable.
+	Decode(r io.Reader, v any) error
+}
+
+var (
+	// JSONCodec encodes and decodes values via the [encoding/json] package.
+	// Unknown fields are rejected while decoding.
+	JSONCodec Codec = jsonCodec{}
+	// XMLCodec encodes and decodes values via the [encoding/xml] package. It
+	// is not registered by default, since browsers prefer XML over JSON in
+	// their Accept header.
+	XMLCodec Codec = xmlCodec{}
+)
+
+var registry = struct {
+	sync.RWMutex
+	codecs []Codec
+}{codecs: []Codec{JSONCodec}}
+
+// RegisterCodec adds a codec to the registry, replacing any existing one with
+// the same media type. When the client accepts several media types equally,
+// codecs are preferred in the order they were registered. Only JSON is
+// registered by default.
+//
+// Example:
+//
+//	grape.RegisterCodec(grape.XMLCodec)
+func RegisterCodec(codec Codec) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	mediaType := strings.ToLower(codec.MediaType())
+	i := slices.IndexFunc(registry.codecs, func(c Codec) bool {
+		return strings.ToLower(c.MediaType()) == mediaType
+	})
+	if i == -1 {
+		registry.codecs = append(registry.codecs, codec)
+		return
+	}
+	registry.codecs[i] = codec
+}
+
+// Write writes back data, similar to [WriteJSON], but encodes it with a codec
+// from the registry. If the request is provided via [WithRequest], the codec
+// is picked based on its Accept header; responding with 406 status code if none
+// is acceptable. If the data cannot be encoded by the preferred codec, such as
+// maps with XML, the next acceptable one is tried. Otherwise, JSON is used.
+func Write(ctx context.Context, w http.ResponseWriter, opts ...WriteOpts) {
+	opt := defaultWriteOptions()
+	for _, o := range opts {
+		o(opt)
+	}
+
+	codecs := []Codec{JSONCodec}
+	if opt.request != nil && opt.data != nil {
+		codecs = negotiate(opt.request.Header.Get("Accept"))
+		if len(codecs) == 0 {
+			ExtractFromErr(ctx, w, errs.NotAcceptable())
+			return
+		}
+	}
+	write(ctx, w, opt, codecs...)
+}
+
+// Read decodes the request's body with a codec from the registry, based on its
+// Content-Type header. It is similar to [ReadJSON], except that its errors are
+// of type [errs.Error] and can be passed to [ExtractFromErr] as is: 415 if the
+// content type is not supported, and 400 for decoding or validation failures.
+func Read[T any](
+	w http.ResponseWriter, r *http.Request, opts ...ReadOpts[T],
+) (*T, error) {
+	codec, err := codecFor(r)
+	if err != nil {
+		return nil, err
+	}
+	opt := &readOptions[T]{maxBodySize: defaultMaxBodySize}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	dst := new(T)
+	r.Body = http.MaxBytesReader(w, r.Body, opt.maxBodySize)
+	if err := codec.Decode(r.Body, dst); err != nil {
+		return nil, badRequest(err)
+	}
+	if err := validate(dst); err != nil {
+		return nil, badRequest(err)
+	}
+	return dst, nil
+}
+
+// codecFor returns the codec matching the request's content type, or an
+// [errs.UnsupportedMediaType] error if there is none.
+func codecFor(r *http.Request) (Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil {
+		registry.RLock()
+		defer registry.RUnlock()
+		for _, c := range registry.codecs {
+			if strings.EqualFold(c.MediaType(), mediaType) {
+				return c, nil
+			}
+		}
+	}
+	return nil, errs.UnsupportedMediaType(
+		errs.WithMsg(fmt.Sprintf("content type %q is not supported", mediaType)),
+	)
+}
+
+// negotiate returns the acceptable codecs, ordered by their quality in the
+// Accept header, and then by their registration order. An empty header accepts
+// any media type.
+func negotiate(accept string) []Codec {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if strings.TrimSpace(accept) == "" {
+		return slices.Clone(registry.codecs)
+	}
+	type candidate struct {
+		codec Codec
+		q     float64
+	}
+	ranges := parseAccept(accept)
+	var candidates []candidate
+	for _, c := range registry.codecs {
+		if q := acceptQuality(ranges, c.MediaType()); q > 0 {
+			candidates = append(candidates, candidate{codec: c, q: q})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.q, a.q)
+	})
+	codecs := make([]Codec, len(candidates))
+	for i, c := range candidates {
+		codecs[i] = c.codec
+	}
+	return codecs
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching the
+// media type, or zero if none does.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(strings.ToLower(mediaType), "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil {
+		if err := dec.Decode(&struct{}{}); err != io.EOF {
+			return errors.New("body must only contain a single JSON value")
+		}
+		return nil
+	}
+	return jsonError(err)
+}
+
+// jsonError translates the errors of [json.Decoder] into human-readable ones.
+func jsonError(err error) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("body contains badly-formed JSON")
+	case errors.As(err, &syntaxError):
+		return fmt.Errorf(
+			"body contains badly-formed JSON (at character %d)",
+			syntaxError.Offset,
+		)
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			return fmt.Errorf(
+				"body contains incorrect JSON type for field %q",
+				unmarshalTypeError.Field,
+			)
+		}
+		return fmt.Errorf(
+			"body contains incorrect JSON type (at character %d)",
+			unmarshalTypeError.Offset,
+		)
+	case errors.As(err, &maxBytesError):
+		return fmt.Errorf(
+			"body must not be larger than %d bytes", maxBytesError.Limit,
+		)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		fieldName := strings.Trim(
+			strings.TrimPrefix(err.Error(), "json: unknown field "), "\"")
+		return fmt.Errorf("body contains unknown key %q", fieldName)
+	default:
+		return fmt.Errorf("unable to parse body: %w", err)
+	}
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Decode(r io.Reader, v any) error {
+	err := xml.NewDecoder(r).Decode(v)
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+	case errors.As(err, &maxBytesError):
+		return fmt.Errorf(
+			"body must not be larger than %d bytes", maxBytesError.Limit,
+		)
+	default:
+		return fmt.Errorf("body contains badly-formed XML: %w", err)
+	}
+}
@@ -0,0 +1,243 @@
+package grape
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hossein1376/grape/errs"
+)
+
+type codecItem struct {
+	XMLName xml.Name `json:"-" xml:"item"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+// withCodecs registers the codecs for the duration of the test.
+func withCodecs(t *testing.T, codecs ...Codec) {
+	t.Helper()
+	registry.RLock()
+	saved := slices.Clone(registry.codecs)
+	registry.RUnlock()
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.codecs = saved
+		registry.Unlock()
+	})
+	for _, c := range codecs {
+		RegisterCodec(c)
+	}
+}
+
+func TestWrite_NegotiatesAccept(t *testing.T) {
+	withCodecs(t, XMLCodec)
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: "application/json"},
+		{accept: "*/*", want: "application/json"},
+		{accept: "application/xml", want: "application/xml"},
+		{accept: "text/html, application/*;q=0.5", want: "application/json"},
+		{
+			accept: "application/json;q=0.8, application/xml",
+			want:   "application/xml",
+		},
+		{
+			accept: "*/*;q=0.9, application/json;q=0.1",
+			want:   "application/xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+
+			Write(
+				context.TODO(),
+				rec,
+				WithRequest(req),
+				WithData(codecItem{Name: "grape"}),
+			)
+			if ct := rec.Header().Get("Content-Type"); ct != tt.want {
+				t.Fatalf("expected content-type %q, got %q", tt.want, ct)
+			}
+		})
+	}
+}
+
+func TestWrite_NotAcceptable(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html, application/json;q=0")
+	rec := httptest.NewRecorder()
+
+	Write(context.TODO(), rec, WithRequest(req), WithData(Map{"x": "y"}))
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected status %d, got %d", http.StatusNotAcceptable, rec.Code)
+	}
+}
+
+func TestWrite_BrowserAcceptDefaultsToJSON(t *testing.T) {
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	h := Handle(func(ctx context.Context, req *struct{}) (user, error) {
+		return user{ID: 1, Name: "a"}, nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(
+		"Accept",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected JSON content type, got %q", ct)
+	}
+	if body := rec.Body.String(); body != `{"id":1,"name":"a"}` {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+func TestWrite_FallsBackOnMarshalError(t *testing.T) {
+	withCodecs(t, XMLCodec)
+	h := Handle(func(ctx context.Context, req *struct{}) (Map, error) {
+		return Map{"name": "grape"}, nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(
+		"Accept",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected JSON content type, got %q", ct)
+	}
+	if body := rec.Body.String(); body != `{"name":"grape"}` {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+func TestRead_PicksDecoder(t *testing.T) {
+	withCodecs(t, XMLCodec)
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "application/json", body: `{"name":"grape"}`},
+		{
+			contentType: "application/xml; charset=utf-8",
+			body:        `<item><name>grape</name></item>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(
+				http.MethodPost, "/", strings.NewReader(tt.body),
+			)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			got, err := Read[codecItem](httptest.NewRecorder(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Name != "grape" {
+				t.Fatalf("unexpected value: %+v", got)
+			}
+		})
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	withCodecs(t, XMLCodec)
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{
+			name:        "unsupported",
+			contentType: "text/plain",
+			body:        "grape",
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "malformed",
+			contentType: "application/xml",
+			body:        "<item>",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "empty",
+			contentType: "application/json",
+			status:      http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(
+				http.MethodPost, "/", strings.NewReader(tt.body),
+			)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			_, err := Read[codecItem](httptest.NewRecorder(), req)
+			var e errs.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected errs.Error, got %v", err)
+			}
+			if e.HTTPStatusCode != tt.status {
+				t.Fatalf(
+					"expected status %d, got %d", tt.status, e.HTTPStatusCode,
+				)
+			}
+		})
+	}
+}
+
+type textCodec struct{}
+
+func (textCodec) MediaType() string { return "text/plain" }
+
+func (textCodec) Marshal(v any) ([]byte, error) {
+	return []byte(v.(codecItem).Name), nil
+}
+
+func (textCodec) Decode(r io.Reader, v any) error {
+	b, err := io.ReadAll(r)
+	v.(*codecItem).Name = string(b)
+	return err
+}
+
+func TestRegisterCodec(t *testing.T) {
+	withCodecs(t, textCodec{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/plain")
+	rec := httptest.NewRecorder()
+	Write(context.TODO(), rec, WithRequest(req), WithData(codecItem{Name: "x"}))
+	if got := rec.Body.String(); got != "x" {
+		t.Fatalf("expected body %q, got %q", "x", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("y"))
+	req.Header.Set("Content-Type", "text/plain")
+	item, err := Read[codecItem](rec, req)
+	if err != nil || item.Name != "y" {
+		t.Fatalf("unexpected result: %+v, %v", item, err)
+	}
+}
@@ -0,0 +1,325 @@
+package grape
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultCompressMinSize = 1024
+
+type compressOption struct {
+	level        int
+	minSize      int
+	contentTypes []string
+}
+
+func defaultCompressOptions() *compressOption {
+	return &compressOption{
+		level:   gzip.DefaultCompression,
+		minSize: defaultCompressMinSize,
+		contentTypes: []string{
+			"text/",
+			"application/json",
+			"application/problem+json",
+			"application/x-ndjson",
+			"application/javascript",
+			"application/xml",
+			"image/svg+xml",
+		},
+	}
+}
+
+type CompressOpts func(*compressOption)
+
+// WithCompressionLevel sets the compression level, which must be one of the
+// levels defined in [compress/flate]. By default, [gzip.DefaultCompression] is
+// used.
+func WithCompressionLevel(level int) CompressOpts {
+	return func(o *compressOption) {
+		o.level = level
+	}
+}
+
+// WithMinSize sets the minimum size of the response body, in bytes, to be
+// compressed. Smaller bodies are not worth the overhead. By default, it is
+// 1024 bytes.
+func WithMinSize(size int) CompressOpts {
+	return func(o *compressOption) {
+		o.minSize = size
+	}
+}
+
+// WithContentTypes overrides the media types which are compressed. Values
+// ending in a slash match all the subtypes, such as "text/".
+func WithContentTypes(contentTypes ...string) CompressOpts {
+	return func(o *compressOption) {
+		o.contentTypes = contentTypes
+	}
+}
+
+// encoder is implemented by both [gzip.Writer] and [zlib.Writer].
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress returns a middleware compressing the response bodies with gzip or
+// deflate, based on the Accept-Encoding header of the request. Bodies smaller
+// than the minimum size, event streams, and responses which already have a
+// Content-Encoding are sent as is. The Content-Length header is removed from
+// compressed responses.
+//
+// Example:
+//
+//	r.Use(grape.Compress(grape.WithMinSize(512)))
+func Compress(opts ...CompressOpts) func(http.Handler) http.Handler {
+	opt := defaultCompressOptions()
+	for _, o := range opts {
+		o(opt)
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, err := gzip.NewWriterLevel(io.Discard, opt.level)
+			if err != nil {
+				w = gzip.NewWriter(io.Discard)
+			}
+			return w
+		}},
+		// The deflate content coding is the zlib format, as defined in
+		// RFC 9110; not the raw output of compress/flate.
+		"deflate": {New: func() any {
+			w, err := zlib.NewWriterLevel(io.Discard, opt.level)
+			if err != nil {
+				w = zlib.NewWriter(io.Discard)
+			}
+			return w
+		}},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(w.Header().Values("Vary"), "Accept-Encoding") {
+				w.Header().Add("Vary", "Accept-Encoding")
+			}
+			encoding := negotiateEncoding(
+				r.Header.Get("Accept-Encoding"), "gzip", "deflate",
+			)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				opt:            opt,
+				pool:           pools[encoding],
+				encoding:       encoding,
+			}
+			// Not deferred, so nothing is written if the handler panics.
+			next.ServeHTTP(cw, r)
+			cw.close()
+		})
+	}
+}
+
+// negotiateEncoding returns the coding preferred by the client among the
+// supported ones, or an empty string if none is acceptable. On equal quality,
+// the coding which comes first in supported is chosen. As defined in RFC 9110,
+// "*" only applies to the codings not listed explicitly.
+func negotiateEncoding(acceptEncoding string, supported ...string) string {
+	listed := make(map[string]float64)
+	wildcard := 0.0
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if coding == "*" {
+			wildcard = q
+		} else {
+			listed[coding] = q
+		}
+	}
+
+	var best string
+	var bestQ float64
+	for _, coding := range supported {
+		q, ok := listed[coding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the beginning of the body, until it can decide
+// whether the response should be compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	opt      *compressOption
+	pool     *sync.Pool
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool
+	enc      encoder
+}
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.decided || statusCode < 200 {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.opt.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements [http.Flusher].
+func (w *compressWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError writes the buffered data, and flushes the underlying writer. If
+// the decision has not been made yet, the response is compressed regardless of
+// its size, since it is being streamed.
+func (w *compressWriter) FlushError() error {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return err
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements [http.Hijacker].
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.decided = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer, for [http.ResponseController].
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header, choosing whether to compress the body or not, and
+// then writes the buffered data.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if large && w.compressible() {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.enc = w.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" ||
+		strings.Contains(h.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+
+	ct := h.Get("Content-Type")
+	if ct == "" && len(w.buf) != 0 {
+		ct = http.DetectContentType(w.buf)
+		h.Set("Content-Type", ct)
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+	for _, allowed := range w.opt.contentTypes {
+		if mediaType == allowed || strings.HasSuffix(allowed, "/") &&
+			strings.HasPrefix(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// close writes the remaining buffered data, and finalizes the compressed
+// stream; returning the encoder to the pool.
+func (w *compressWriter) close() {
+	if !w.decided {
+		// The body is smaller than the minimum size; it is written as is.
+		_ = w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	w.enc.Reset(io.Discard)
+	w.pool.Put(w.enc)
+	w.enc = nil
+}
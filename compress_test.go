@@ -0,0 +1,166 @@
+package grape
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveCompressed(
+	h http.Handler, acceptEncoding string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	Compress()(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func largeJSONHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := make([]string, 500)
+		for i := range items {
+			items[i] = "grape"
+		}
+		w.Header().Set("Content-Length", "9999")
+		Respond(r.Context(), w, http.StatusCreated, items)
+	})
+}
+
+func TestCompress_Gzip(t *testing.T) {
+	rec := serveCompressed(largeJSONHandler(), "deflate;q=0.5, gzip")
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", rec.Code)
+	}
+	h := rec.Header()
+	if h.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", h.Get("Content-Encoding"))
+	}
+	if h.Get("Content-Length") != "" {
+		t.Fatalf("expected content length to be removed")
+	}
+	if h.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected Vary header, got %q", h.Get("Vary"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	body, _ := io.ReadAll(zr)
+	if !strings.HasPrefix(string(body), `["grape","grape"`) {
+		t.Fatalf("unexpected decompressed body: %.40s", body)
+	}
+}
+
+func TestCompress_Deflate(t *testing.T) {
+	rec := serveCompressed(largeJSONHandler(), "deflate")
+	if rec.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("expected deflate encoding")
+	}
+	zr, err := zlib.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("zlib reader: %v", err)
+	}
+	if body, _ := io.ReadAll(zr); len(body) == 0 {
+		t.Fatalf("expected decompressed body")
+	}
+}
+
+func TestCompress_Skipped(t *testing.T) {
+	small := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Respond(r.Context(), w, http.StatusOK, Map{"a": 1})
+	})
+	encoded := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	image := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 2048))
+	})
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		acceptEncoding string
+		encoding       string
+	}{
+		{name: "not accepted", handler: largeJSONHandler()},
+		{
+			name:           "refused",
+			handler:        largeJSONHandler(),
+			acceptEncoding: "gzip;q=0",
+		},
+		{name: "small body", handler: small, acceptEncoding: "gzip"},
+		{
+			name:           "already encoded",
+			handler:        encoded,
+			acceptEncoding: "gzip",
+			encoding:       "br",
+		},
+		{name: "content type", handler: image, acceptEncoding: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveCompressed(tt.handler, tt.acceptEncoding)
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("expected encoding %q, got %q", tt.encoding, got)
+			}
+			if rec.Body.Len() == 0 {
+				t.Fatalf("expected body to be written")
+			}
+		})
+	}
+}
+
+func TestCompress_EventStream(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sse, err := NewSSE(w, r)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		defer sse.Close()
+		sse.Send("", "", strings.Repeat("x", 2048))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.Background())
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	Compress()(h).ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("event streams must not be compressed")
+	}
+	if !rec.Flushed || !strings.HasPrefix(rec.Body.String(), "data: ") {
+		t.Fatalf("unexpected event stream body: %.20q", rec.Body.String())
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"gzip, deflate":            "gzip",
+		"deflate;q=0.5, gzip":      "gzip",
+		"gzip;q=0.5, deflate":      "deflate",
+		"br":                       "",
+		"*":                        "gzip",
+		"gzip;q=0, *":              "deflate",
+		"*;q=0, gzip":              "gzip",
+		"gzip;q=0, deflate;q=0, *": "",
+		"identity, *;q=0":          "",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header, "gzip", "deflate"); got != want {
+			t.Fatalf("%q: expected %q, got %q", header, want, got)
+		}
+	}
+}
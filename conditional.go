@@ -0,0 +1,109 @@
+package grape
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WithRequest provides the incoming request to [WriteJSON], so its conditional
+// headers, If-None-Match and If-Modified-Since, can be evaluated.
+func WithRequest(r *http.Request) WriteOpts {
+	return func(o *writeOption) {
+		o.request = r
+	}
+}
+
+// WithETag sets the ETag header of the response to the given entity tag. It is
+// quoted if necessary; weak tags must be provided with their W/ prefix.
+func WithETag(etag string) WriteOpts {
+	return func(o *writeOption) {
+		if !strings.HasSuffix(etag, `"`) {
+			etag = `"` + etag + `"`
+		}
+		o.etag = etag
+	}
+}
+
+// WithStrongETag computes a strong ETag from the hash of the marshalled body,
+// unless one is provided by [WithETag].
+func WithStrongETag() WriteOpts {
+	return func(o *writeOption) {
+		o.strongETag = true
+	}
+}
+
+// WithLastModified sets the Last-Modified header of the response.
+func WithLastModified(t time.Time) WriteOpts {
+	return func(o *writeOption) {
+		o.lastModified = t
+	}
+}
+
+// writeNotModified sets the validator headers of the response. Then, if the
+// client's copy is fresh, it writes a 304 response and reports true.
+func writeNotModified(
+	w http.ResponseWriter, opt *writeOption, body []byte,
+) bool {
+	etag := opt.etag
+	if etag == "" && opt.strongETag && body != nil {
+		sum := sha256.Sum256(body)
+		etag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	}
+	h := w.Header()
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !opt.lastModified.IsZero() {
+		h.Set("Last-Modified", opt.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	r := opt.request
+	if r == nil || opt.status != http.StatusOK ||
+		r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if !notModified(r, etag, opt.lastModified) {
+		return false
+	}
+
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates the conditional headers of the request, as described
+// in RFC 9110. If-Modified-Since is ignored if If-None-Match is present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for candidate := range strings.SplitSeq(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakMatch(candidate, etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a resolution of one second.
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// weakMatch compares two entity tags, ignoring their weakness indicator.
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
@@ -0,0 +1,224 @@
+package grape
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type corsOption struct {
+	allowAll    bool
+	origins     []string
+	wildcards   [][2]string
+	originFunc  func(origin string) bool
+	methods     []string
+	headers     []string
+	exposed     []string
+	maxAge      time.Duration
+	credentials bool
+}
+
+func defaultCORSOptions() *corsOption {
+	return &corsOption{
+		methods: []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		headers: []string{
+			"Accept",
+			"Authorization",
+			"Cache-Control",
+			"Content-Type",
+			"X-CSRF-Token",
+			"X-Requested-With",
+		},
+	}
+}
+
+type CORSOpts func(*corsOption)
+
+// WithAllowedOrigins sets the origins which are allowed to make cross-origin
+// requests. Origins are matched case-insensitively, and may be in one of the
+// following forms:
+//
+//	"*"                      // any origin
+//	"https://example.com"    // exact match
+//	"https://*.example.com"  // any subdomain of example.com
+func WithAllowedOrigins(origins ...string) CORSOpts {
+	return func(o *corsOption) {
+		for _, origin := range origins {
+			origin = strings.ToLower(origin)
+			switch {
+			case origin == "*":
+				o.allowAll = true
+			case strings.Contains(origin, "*"):
+				prefix, suffix, _ := strings.Cut(origin, "*")
+				o.wildcards = append(o.wildcards, [2]string{prefix, suffix})
+			default:
+				o.origins = append(o.origins, origin)
+			}
+		}
+	}
+}
+
+// WithOriginFunc sets a predicate to decide whether an origin is allowed. It is
+// consulted if the origin is not matched by [WithAllowedOrigins].
+func WithOriginFunc(fn func(origin string) bool) CORSOpts {
+	return func(o *corsOption) {
+		o.originFunc = fn
+	}
+}
+
+// WithAllowedMethods overrides the methods allowed in cross-origin requests.
+// By default, GET, HEAD, POST, PUT, PATCH and DELETE are allowed.
+func WithAllowedMethods(methods ...string) CORSOpts {
+	return func(o *corsOption) {
+		o.methods = methods
+	}
+}
+
+// WithAllowedHeaders overrides the request headers allowed in cross-origin
+// requests. A single "*" allows any header requested by the client.
+func WithAllowedHeaders(headers ...string) CORSOpts {
+	return func(o *corsOption) {
+		o.headers = headers
+	}
+}
+
+// WithExposedHeaders sets the response headers which the browser is allowed to
+// expose to the client's scripts.
+func WithExposedHeaders(headers ...string) CORSOpts {
+	return func(o *corsOption) {
+		o.exposed = headers
+	}
+}
+
+// WithMaxAge sets how long the results of a preflight request can be cached.
+func WithMaxAge(maxAge time.Duration) CORSOpts {
+	return func(o *corsOption) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithCredentials allows cross-origin requests to include credentials, such as
+// cookies. In this case, the matched origin is reflected instead of "*". It
+// cannot be combined with allowing any origin via [WithAllowedOrigins].
+func WithCredentials() CORSOpts {
+	return func(o *corsOption) {
+		o.credentials = true
+	}
+}
+
+// CORS returns a middleware handling Cross-Origin Resource Sharing. Without any
+// options, no origin is allowed. Preflight requests of allowed origins are
+// answered with 204 status code, and never reach the next handler.
+//
+// To answer preflight requests of routes without an OPTIONS handler, the
+// middleware must be registered via [Router.UseAll].
+//
+// It panics if any origin is allowed together with [WithCredentials], since it
+// would let every website make credentialed requests on behalf of the user.
+//
+// Example:
+//
+//	r.UseAll(grape.CORS(
+//		grape.WithAllowedOrigins("https://example.com", "https://*.example.com"),
+//		grape.WithCredentials(),
+//		grape.WithMaxAge(time.Hour),
+//	))
+func CORS(opts ...CORSOpts) func(http.Handler) http.Handler {
+	opt := defaultCORSOptions()
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.allowAll && opt.credentials {
+		panic("grape: CORS cannot allow any origin with credentials")
+	}
+	methods := strings.Join(opt.methods, ", ")
+	headers := strings.Join(opt.headers, ", ")
+	exposed := strings.Join(opt.exposed, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+			h := w.Header()
+
+			// Unless any origin is allowed, the response depends on the
+			// value of the Origin header.
+			if !opt.allowAll {
+				h.Add("Vary", "Origin")
+			}
+			if origin == "" || !opt.allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if opt.allowAll {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opt.credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers == "*" {
+				requested := r.Header.Get("Access-Control-Request-Headers")
+				if requested != "" {
+					h.Set("Access-Control-Allow-Headers", requested)
+				}
+			} else if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			if opt.maxAge > 0 {
+				h.Set(
+					"Access-Control-Max-Age",
+					strconv.Itoa(int(opt.maxAge.Seconds())),
+				)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func (o *corsOption) allowed(origin string) bool {
+	if o.allowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if slices.Contains(o.origins, lower) {
+		return true
+	}
+	for _, w := range o.wildcards {
+		prefix, suffix := w[0], w[1]
+		if len(lower) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(lower, prefix) &&
+			strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return o.originFunc != nil && o.originFunc(origin)
+}
@@ -0,0 +1,135 @@
+package grape
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func serveCORS(
+	t *testing.T, r *Router, method, origin string, header http.Header,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/items", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func newCORSRouter(opts ...CORSOpts) *Router {
+	r := NewRouter()
+	r.UseAll(CORS(opts...))
+	r.Get("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return r
+}
+
+func TestCORS_Origins(t *testing.T) {
+	r := newCORSRouter(
+		WithAllowedOrigins("https://example.com", "https://*.example.org"),
+		WithOriginFunc(func(origin string) bool {
+			return origin == "http://localhost:3000"
+		}),
+		WithCredentials(),
+		WithExposedHeaders("X-Total"),
+	)
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://example.com", allowed: true},
+		{origin: "https://EXAMPLE.com", allowed: true},
+		{origin: "https://api.example.org", allowed: true},
+		{origin: "https://example.org", allowed: false},
+		{origin: "http://localhost:3000", allowed: true},
+		{origin: "https://evil.com", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			rec := serveCORS(t, r, http.MethodGet, tt.origin, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rec.Code)
+			}
+			got := rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Fatalf("expected origin to be reflected, got %q", got)
+			}
+			if !tt.allowed && got != "" {
+				t.Fatalf("expected no allowed origin, got %q", got)
+			}
+			if !slices.Contains(rec.Header().Values("Vary"), "Origin") {
+				t.Fatalf("expected Vary: Origin, got %v", rec.Header()["Vary"])
+			}
+			if tt.allowed &&
+				rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Fatalf("expected credentials to be allowed")
+			}
+			if tt.allowed &&
+				rec.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+				t.Fatalf("expected exposed headers")
+			}
+		})
+	}
+}
+
+func TestCORS_AllowAllWithoutCredentials(t *testing.T) {
+	r := newCORSRouter(WithAllowedOrigins("*"))
+	rec := serveCORS(t, r, http.MethodGet, "https://any.com", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected wildcard origin, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no credentials header, got %q", got)
+	}
+}
+
+func TestCORS_PreflightWithoutOptionsHandler(t *testing.T) {
+	r := newCORSRouter(
+		WithAllowedOrigins("https://example.com"),
+		WithAllowedMethods(http.MethodGet, http.MethodPost),
+		WithAllowedHeaders("*"),
+		WithMaxAge(10*time.Minute),
+	)
+	header := http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"X-Custom"},
+	}
+	rec := serveCORS(t, r, http.MethodOptions, "https://example.com", header)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", rec.Code)
+	}
+	h := rec.Header()
+	if got := h.Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Fatalf("unexpected allowed methods: %q", got)
+	}
+	if got := h.Get("Access-Control-Allow-Headers"); got != "X-Custom" {
+		t.Fatalf("unexpected allowed headers: %q", got)
+	}
+	if got := h.Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("unexpected max age: %q", got)
+	}
+
+	rec = serveCORS(t, r, http.MethodOptions, "https://evil.com", header)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected disallowed preflight to have no CORS headers")
+	}
+}
+
+func TestCORS_AllowAllWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic for any origin with credentials")
+		}
+	}()
+	CORS(WithAllowedOrigins("*"), WithCredentials())
+}
@@ -4,6 +4,7 @@ package errs
 
 import (
 	"fmt"
+	"maps"
 	"net/http"
 )
 
@@ -13,6 +14,12 @@ type Error struct {
 	Err            error
 	HTTPStatusCode int
 	Message        string
+	// Type is a URI reference identifying the problem type, as described in
+	// RFC 9457. It is only used in problem details responses.
+	Type string
+	// extensions are additional members of problem details responses. They
+	// are held behind a pointer, so Error stays comparable.
+	extensions *map[string]any
 }
 
 // New returns a new instance of the [Error] object.
@@ -42,6 +49,15 @@ func (e Error) Error() string {
 	return fmt.Sprintf("[%d] %s", e.HTTPStatusCode, errMsg)
 }
 
+// Extensions returns a copy of the extension members added by [WithExtension],
+// or nil if there are none.
+func (e Error) Extensions() map[string]any {
+	if e.extensions == nil {
+		return nil
+	}
+	return maps.Clone(*e.extensions)
+}
+
 // Unwrap returns the underlying Err.
 func (e Error) Unwrap() error {
 	return e.Err
@@ -79,6 +95,22 @@ func NotFound(opts ...Options) Error {
 	return New(http.StatusNotFound, opts...)
 }
 
+// MethodNotAllowed indicates the resource does not support the request's
+// method.
+//
+// HTTP: 405
+func MethodNotAllowed(opts ...Options) Error {
+	return New(http.StatusMethodNotAllowed, opts...)
+}
+
+// NotAcceptable indicates the server can't produce a response in any of the
+// media types accepted by the client.
+//
+// HTTP: 406
+func NotAcceptable(opts ...Options) Error {
+	return New(http.StatusNotAcceptable, opts...)
+}
+
 // Conflict indicates operation was rejected because the request is in conflict
 // with the system's current state.
 //
@@ -87,6 +119,14 @@ func Conflict(opts ...Options) Error {
 	return New(http.StatusConflict, opts...)
 }
 
+// UnsupportedMediaType indicates the request's body is in a media type which
+// is not supported by the server.
+//
+// HTTP: 415
+func UnsupportedMediaType(opts ...Options) Error {
+	return New(http.StatusUnsupportedMediaType, opts...)
+}
+
 // TooMany indicates some resource has been exhausted, and client may need to
 // wait some time before retrying.
 //
@@ -40,3 +40,28 @@ func WithErrMsg(err error) Options {
 		e.Message = msg
 	}
 }
+
+// WithType sets the problem type URI of the custom [Error] type. It identifies
+// the kind of the problem in problem details responses, as described in
+// RFC 9457.
+func WithType(uri string) Options {
+	return func(e *Error) {
+		e.Type = uri
+	}
+}
+
+// WithExtension adds an extension member to the custom [Error] type, which will
+// be included in problem details responses. Subsequent calls with the same key
+// overwrite the previous value.
+func WithExtension(key string, value any) Options {
+	return func(e *Error) {
+		// Copies of the error may share the map, so it is never modified
+		// in place.
+		extensions := e.Extensions()
+		if extensions == nil {
+			extensions = make(map[string]any)
+		}
+		extensions[key] = value
+		e.extensions = &extensions
+	}
+}
@@ -0,0 +1,155 @@
+package grape
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/hossein1376/grape/validator"
+)
+
+// ReadForm decodes an application/x-www-form-urlencoded body into a new
+// instance of T, according to the `form` tags of its fields:
+//
+//	type signupForm struct {
+//		Email   string   `form:"email,required"`
+//		Roles   []string `form:"role"`
+//		Address struct {
+//			City string `form:"city"`
+//		} `form:"address"`
+//	}
+//
+// Nested structs are filled from dotted keys, such as "address.city". Values
+// are parsed the same way as in [Bind], and fields without a value are left
+// untouched, unless they have the `required` option. All missing and
+// malformed fields are reported together, as a [validator.ValidationError]
+// keyed by their full name.
+//
+// If T implements the Validate method, it is called after decoding. By default,
+// the maximum body size is 1MB, which can be changed using [WithMaxTotalSize].
+func ReadForm[T any](
+	w http.ResponseWriter, r *http.Request, opts ...ReadOpts[T],
+) (*T, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return nil, errors.New(
+			"content type is not application/x-www-form-urlencoded",
+		)
+	}
+	opt := &readOptions[T]{maxBodySize: defaultMaxBodySize}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, opt.maxBodySize)
+	if err := r.ParseForm(); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return nil, fmt.Errorf(
+				"body must not be larger than %d bytes", maxBytesError.Limit,
+			)
+		}
+		return nil, fmt.Errorf("unable to parse body: %w", err)
+	}
+
+	dst := new(T)
+	if err := decodeForm(r.PostForm, dst); err != nil {
+		return nil, err
+	}
+	return dst, validate(dst)
+}
+
+// decodeForm fills the fields of the struct pointed to by dst from the form
+// values. Refer to [ReadForm] for more details.
+func decodeForm(values url.Values, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: expected pointer to struct, got %T", dst)
+	}
+
+	verrs := make(validator.ValidationError)
+	decodeFormStruct(values, "", v.Elem(), verrs)
+	if len(verrs) != 0 {
+		return verrs
+	}
+	return nil
+}
+
+func decodeFormStruct(
+	values url.Values,
+	prefix string,
+	v reflect.Value,
+	verrs validator.ValidationError,
+) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("form")
+		if field.Anonymous && !ok && field.Type.Kind() == reflect.Struct {
+			decodeFormStruct(values, prefix, v.Field(i), verrs)
+			continue
+		}
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		key := prefix + name
+
+		if fv := v.Field(i); isFormStruct(fv) {
+			decodeFormNested(values, key+".", fv, verrs)
+			continue
+		}
+		vals := values[key]
+		if len(vals) == 0 {
+			if hasTagOption(opts, "required") {
+				verrs[key] = append(verrs[key], "is required")
+			}
+			continue
+		}
+		if err := setField(v.Field(i), vals); err != nil {
+			verrs[key] = append(verrs[key], bindErrMsg(err))
+		}
+	}
+}
+
+// decodeFormNested fills a nested struct, or a pointer to one. Pointers are
+// only allocated if the form has a key with the given prefix.
+func decodeFormNested(
+	values url.Values,
+	prefix string,
+	v reflect.Value,
+	verrs validator.ValidationError,
+) {
+	if v.Kind() != reflect.Pointer {
+		decodeFormStruct(values, prefix, v, verrs)
+		return
+	}
+	if v.IsNil() {
+		var found bool
+		for key := range values {
+			if strings.HasPrefix(key, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	decodeFormStruct(values, prefix, v.Elem(), verrs)
+}
+
+// isFormStruct reports whether the value is a struct, or a pointer to one,
+// which is filled from dotted keys rather than parsed from a single value.
+func isFormStruct(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isScalar(reflect.Zero(t))
+}
@@ -0,0 +1,114 @@
+package grape
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hossein1376/grape/validator"
+)
+
+type formAddress struct {
+	City string `form:"city,required"`
+	Zip  *int   `form:"zip"`
+}
+
+type signupForm struct {
+	Email    string       `form:"email,required"`
+	Roles    []string     `form:"role"`
+	Age      uint8        `form:"age"`
+	Born     time.Time    `form:"born"`
+	Address  formAddress  `form:"address"`
+	Billing  *formAddress `form:"billing"`
+	Internal string       `form:"-"`
+}
+
+func (f signupForm) Validate() error {
+	if !strings.Contains(f.Email, "@") {
+		return errors.New("invalid email")
+	}
+	return nil
+}
+
+func formRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestReadForm_Success(t *testing.T) {
+	body := url.Values{
+		"email":        {"a@b.c"},
+		"role":         {"admin", "editor"},
+		"age":          {"30"},
+		"born":         {"1990-01-02T00:00:00Z"},
+		"address.city": {"Tehran"},
+		"address.zip":  {"1234"},
+		"Internal":     {"x"},
+	}.Encode()
+
+	got, err := ReadForm[signupForm](httptest.NewRecorder(), formRequest(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Email != "a@b.c" || len(got.Roles) != 2 || got.Age != 30 {
+		t.Fatalf("unexpected form: %+v", got)
+	}
+	if got.Born.Year() != 1990 {
+		t.Fatalf("expected born in 1990, got %v", got.Born)
+	}
+	if got.Address.City != "Tehran" || *got.Address.Zip != 1234 {
+		t.Fatalf("unexpected address: %+v", got.Address)
+	}
+	if got.Billing != nil {
+		t.Fatalf("expected nil billing, got %+v", got.Billing)
+	}
+	if got.Internal != "" {
+		t.Fatalf("expected ignored field to be empty, got %q", got.Internal)
+	}
+}
+
+func TestReadForm_FieldErrors(t *testing.T) {
+	body := url.Values{
+		"age":          {"300"},
+		"billing.zip":  {"abc"},
+		"address.city": {"Tehran"},
+	}.Encode()
+
+	_, err := ReadForm[signupForm](httptest.NewRecorder(), formRequest(body))
+	var verrs validator.ValidationError
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	for _, key := range []string{"email", "age", "billing.city", "billing.zip"} {
+		if len(verrs[key]) == 0 {
+			t.Fatalf("expected error for %q, got %v", key, verrs)
+		}
+	}
+}
+
+func TestReadForm_Errors(t *testing.T) {
+	req := formRequest("email=a@b.c")
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := ReadForm[signupForm](httptest.NewRecorder(), req); err == nil {
+		t.Fatal("expected content type error")
+	}
+
+	_, err := ReadForm(
+		httptest.NewRecorder(),
+		formRequest("email="+strings.Repeat("a", 100)),
+		WithMaxTotalSize[signupForm](10),
+	)
+	if err == nil || err.Error() != "body must not be larger than 10 bytes" {
+		t.Fatalf("expected size error, got %v", err)
+	}
+
+	_, err = ReadForm[signupForm](httptest.NewRecorder(), formRequest("email=x&address.city=y"))
+	if err == nil || err.Error() != "invalid email" {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
@@ -3,12 +3,14 @@
 package grape
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 )
 
 const defaultMaxBodySize = 1_048_576 // 1mb
@@ -129,11 +131,17 @@ func checkOverflow[T any](s string, t T) (T, error) {
 	return t, nil
 }
 
+// goroutines tracks the goroutines spawned by [Go].
+var goroutines sync.WaitGroup
+
 // Go spawns a new goroutine and will recover in case of panic; logging the
 // error message in Error level. Using this function ensures panicking in other
-// goroutines will not stop the main goroutine.
+// goroutines will not stop the main goroutine. Use [Wait] to wait for them to
+// finish.
 func Go(f func()) {
+	goroutines.Add(1)
 	go func() {
+		defer goroutines.Done()
 		defer func() {
 			if msg := recover(); msg != nil {
 				slog.Error("goroutine panic", slog.Any("message", msg))
@@ -142,3 +150,19 @@ func Go(f func()) {
 		f()
 	}()
 }
+
+// Wait blocks until all goroutines spawned by [Go] have finished, or the
+// context is done; in which case its error is returned.
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		goroutines.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
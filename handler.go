@@ -0,0 +1,91 @@
+package grape
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/hossein1376/grape/errs"
+)
+
+// Handle adapts a typed function into an [http.HandlerFunc]. For each request,
+// a new Req is decoded from the JSON body, and then its fields are filled from
+// the request, as described in [Bind]. If Req implements the following method,
+// it will be called afterward:
+//
+//	Validate() error
+//
+// Decoding and validation errors are responded with 400 status code. Finally,
+// the function is called; its returned error is written by [ExtractFromErr],
+// and otherwise the response is written by [Respond] with 200 status code, in
+// a media type accepted by the client.
+//
+// Requests without a body are allowed for the GET, HEAD, DELETE and OPTIONS
+// methods.
+//
+// Example:
+//
+//	type getUserRequest struct {
+//		ID int `path:"id"`
+//	}
+//
+//	r.Get("/users/{id}", grape.Handle(getUser))
+//
+//	func getUser(ctx context.Context, req *getUserRequest) (User, error) {
+//		// handler's logic
+//	}
+func Handle[Req, Resp any](
+	fn func(ctx context.Context, req *Req) (Resp, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := new(Req)
+
+		if hasBody(r) || !bodyless(r.Method) {
+			if !isJSON(r) {
+				ExtractFromErr(ctx, w, badRequest(errNotJSON))
+				return
+			}
+			if err := decodeJSON(w, r, req, defaultMaxBodySize); err != nil {
+				ExtractFromErr(ctx, w, badRequest(err))
+				return
+			}
+		}
+		if reflect.TypeFor[Req]().Kind() == reflect.Struct {
+			if err := bind(r, req); err != nil {
+				ExtractFromErr(ctx, w, badRequest(err))
+				return
+			}
+		}
+		if err := validate(req); err != nil {
+			ExtractFromErr(ctx, w, badRequest(err))
+			return
+		}
+
+		resp, err := fn(ctx, req)
+		if err != nil {
+			ExtractFromErr(ctx, w, err)
+			return
+		}
+		Respond(ctx, w, http.StatusOK, resp, WithRequest(r))
+	}
+}
+
+func badRequest(err error) error {
+	return errs.BadRequest(errs.WithErr(err), errs.WithErrMsg(err))
+}
+
+// bodyless reports whether requests of the given method are expected to have
+// no body.
+func bodyless(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
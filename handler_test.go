@@ -0,0 +1,135 @@
+package grape
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hossein1376/grape/errs"
+)
+
+type updateItemRequest struct {
+	ID     int64  `json:"-" path:"id"`
+	Notify bool   `json:"-" query:"notify"`
+	Name   string `json:"name"`
+}
+
+func (r updateItemRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type getItemRequest struct {
+	ID   int64    `path:"id"`
+	Tags []string `query:"tag"`
+}
+
+func serveHandle(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := NewRouter()
+	r.Put(
+		"/items/{id}",
+		Handle(func(ctx context.Context, req *updateItemRequest) (Map, error) {
+			if req.ID == 0 {
+				return nil, errs.NotFound()
+			}
+			return Map{"id": req.ID, "name": req.Name, "notify": req.Notify}, nil
+		}),
+	)
+	r.Get(
+		"/items/{id}",
+		Handle(func(ctx context.Context, req *getItemRequest) (Map, error) {
+			return Map{"id": req.ID, "tags": req.Tags}, nil
+		}),
+	)
+
+	var buf *bytes.Buffer
+	if body != "" {
+		buf = bytes.NewBufferString(body)
+	} else {
+		buf = new(bytes.Buffer)
+	}
+	req := httptest.NewRequest(method, target, buf)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandle_DecodesBindsAndResponds(t *testing.T) {
+	rec := serveHandle(
+		t, http.MethodPut, "/items/7?notify=true", `{"name":"grape"}`,
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got["id"] != float64(7) || got["name"] != "grape" || got["notify"] != true {
+		t.Fatalf("unexpected body: %v", got)
+	}
+}
+
+func TestHandle_BodylessMethod(t *testing.T) {
+	rec := serveHandle(t, http.MethodGet, "/items/3?tag=a&tag=b", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var got struct {
+		ID   int64    `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ID != 3 || len(got.Tags) != 2 || got.Tags[1] != "b" {
+		t.Fatalf("unexpected body: %+v", got)
+	}
+}
+
+func TestHandle_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{name: "missing body", target: "/items/1", status: http.StatusBadRequest},
+		{
+			name:   "validation",
+			target: "/items/1",
+			body:   `{"name":""}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid path parameter",
+			target: "/items/abc",
+			body:   `{"name":"x"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "handler error",
+			target: "/items/0",
+			body:   `{"name":"x"}`,
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveHandle(t, http.MethodPut, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -11,30 +11,12 @@ import (
 	"github.com/hossein1376/grape/slogger"
 )
 
-type respWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (w *respWriter) Write(b []byte) (int, error) {
-	if w.statusCode == 0 {
-		w.statusCode = http.StatusOK
-		w.ResponseWriter.WriteHeader(w.statusCode)
-	}
-	return w.ResponseWriter.Write(b)
-}
-
-func (w *respWriter) WriteHeader(statusCode int) {
-	w.statusCode = statusCode
-	w.ResponseWriter.WriteHeader(statusCode)
-}
-
 func LoggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		path := r.URL.Path
 		raw := r.URL.RawQuery
-		rw := &respWriter{ResponseWriter: w}
+		rw := NewResponseWriter(w)
 
 		ip := r.Header.Get("X-Real-Ip")
 		if ip == "" {
@@ -59,7 +41,8 @@ func LoggerMiddleware(next http.Handler) http.Handler {
 				),
 				slog.Group(
 					"resp",
-					slog.Int("status", rw.statusCode),
+					slog.Int("status", rw.Status()),
+					slog.Int64("bytes", rw.BytesWritten()),
 					slog.String("elapsed", time.Since(start).String()),
 				),
 			)
@@ -96,6 +79,11 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// CORSMiddleware allows cross-origin requests from any origin, with a fixed set
+// of methods and headers.
+//
+// Deprecated: Browsers reject the wildcard origin alongside credentials, which
+// this middleware sends. Use [CORS] instead, which is configurable.
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Access-Control-Allow-Origin", "*")
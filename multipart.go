@@ -0,0 +1,357 @@
+package grape
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultMaxUploadSize   = 32 << 20 // 32mb
+	defaultMultipartMemory = 8 << 20  // 8mb
+)
+
+type multipartOption struct {
+	maxUploadSize int64
+	maxFileSize   int64
+	maxFiles      int
+	memoryLimit   int64
+	allowedTypes  []string
+}
+
+type MultipartOpts func(*multipartOption)
+
+// WithMaxUploadSize sets the maximum size of the whole request's body, in
+// bytes. By default, it is 32MB.
+func WithMaxUploadSize(size int64) MultipartOpts {
+	return func(o *multipartOption) {
+		o.maxUploadSize = size
+	}
+}
+
+// WithMaxFileSize sets the maximum size of each file, in bytes. By default,
+// files are only limited by the maximum upload size.
+func WithMaxFileSize(size int64) MultipartOpts {
+	return func(o *multipartOption) {
+		o.maxFileSize = size
+	}
+}
+
+// WithMaxFiles sets the maximum number of files in the request. By default,
+// the number of files is not limited.
+func WithMaxFiles(n int) MultipartOpts {
+	return func(o *multipartOption) {
+		o.maxFiles = n
+	}
+}
+
+// WithMemoryLimit sets the number of bytes kept in memory; the rest of the
+// files are stored in temporary files on disk. By default, it is 8MB.
+func WithMemoryLimit(size int64) MultipartOpts {
+	return func(o *multipartOption) {
+		o.memoryLimit = size
+	}
+}
+
+// WithAllowedFileTypes sets the media types which files may have. The type is
+// detected by sniffing the content via [http.DetectContentType], regardless of
+// what the client claims. Values ending in a slash match all the subtypes, such
+// as "image/". By default, any type is allowed.
+func WithAllowedFileTypes(mediaTypes ...string) MultipartOpts {
+	return func(o *multipartOption) {
+		o.allowedTypes = mediaTypes
+	}
+}
+
+// Multipart holds the parsed fields and files of a multipart/form-data body.
+type Multipart struct {
+	// Fields maps the names of the non-file fields to their values.
+	Fields url.Values
+	// Files maps the names of the file fields to their files.
+	Files map[string][]*File
+}
+
+// Value returns the first value of the named field, or an empty string.
+func (m *Multipart) Value(name string) string {
+	return m.Fields.Get(name)
+}
+
+// File returns the first file of the named field, or nil.
+func (m *Multipart) File(name string) *File {
+	if files := m.Files[name]; len(files) != 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// File is an uploaded file of a multipart body.
+type File struct {
+	// Filename is the name of the file, as provided by the client. It must not
+	// be trusted as a path.
+	Filename string
+	// Size is the size of the file, in bytes.
+	Size int64
+	// ContentType is the media type detected from the file's content.
+	ContentType string
+	// Header is the header of the file's part.
+	Header textproto.MIMEHeader
+
+	// content holds the files kept in memory, and path the ones stored on
+	// disk.
+	content []byte
+	path    string
+}
+
+// Open opens the file for reading. It must be closed by the caller.
+func (f *File) Open() (multipart.File, error) {
+	if f.path != "" {
+		return os.Open(f.path)
+	}
+	return memoryFile{bytes.NewReader(f.content)}, nil
+}
+
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error {
+	return nil
+}
+
+// ReadMultipart reads a multipart/form-data body, and checks its files against
+// the provided limits. Parts are read one at a time, and reading stops at the
+// first violation; so the rest of the body is never read. It returns a
+// human-readable error in case of failure, similar to [ReadJSON].
+//
+// Files larger than the remaining memory limit are stored on disk, and are
+// removed once the request's context is done; which is when the handler
+// returns.
+//
+// Example:
+//
+//	form, err := grape.ReadMultipart(
+//		w, r,
+//		grape.WithMaxFileSize(5<<20),
+//		grape.WithMaxFiles(1),
+//		grape.WithAllowedFileTypes("image/png", "image/jpeg"),
+//	)
+//	if err != nil {
+//		grape.ExtractFromErr(ctx, w, errs.BadRequest(errs.WithErrMsg(err)))
+//		return
+//	}
+//	avatar := form.File("avatar")
+func ReadMultipart(
+	w http.ResponseWriter, r *http.Request, opts ...MultipartOpts,
+) (*Multipart, error) {
+	opt := &multipartOption{
+		maxUploadSize: defaultMaxUploadSize,
+		memoryLimit:   defaultMultipartMemory,
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return nil, errors.New("content type is not multipart/form-data")
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, opt.maxUploadSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, multipartError(err)
+	}
+
+	rd := &multipartReader{
+		opt:        opt,
+		memoryLeft: opt.memoryLimit,
+		fieldsLeft: maxMultipartFields,
+		m: &Multipart{
+			Fields: make(url.Values),
+			Files:  make(map[string][]*File),
+		},
+	}
+	if err := rd.read(mr); err != nil {
+		rd.removeAll()
+		return nil, err
+	}
+	if len(rd.paths) != 0 {
+		context.AfterFunc(r.Context(), rd.removeAll)
+	}
+	return rd.m, nil
+}
+
+// maxMultipartFields is the total size of the non-file fields, the same as
+// the one used by [http.Request.ParseMultipartForm].
+const maxMultipartFields = 10 << 20 // 10mb
+
+type multipartReader struct {
+	opt        *multipartOption
+	memoryLeft int64
+	fieldsLeft int64
+	files      int
+	m          *Multipart
+	// paths holds the files stored on disk.
+	paths []string
+}
+
+func (rd *multipartReader) read(mr *multipart.Reader) error {
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return multipartError(err)
+		}
+		name := part.FormName()
+		switch {
+		case name == "":
+		case part.FileName() == "":
+			err = rd.readField(name, part)
+		default:
+			err = rd.readFile(name, part)
+		}
+		if err != nil {
+			// Closing the part would read the rest of it.
+			return err
+		}
+		part.Close()
+	}
+}
+
+func (rd *multipartReader) readField(name string, part *multipart.Part) error {
+	b, err := io.ReadAll(io.LimitReader(part, rd.fieldsLeft+1))
+	if err != nil {
+		return multipartError(err)
+	}
+	rd.fieldsLeft -= int64(len(b))
+	if rd.fieldsLeft < 0 {
+		return multipartError(multipart.ErrMessageTooLarge)
+	}
+	rd.m.Fields.Add(name, string(b))
+	return nil
+}
+
+func (rd *multipartReader) readFile(name string, part *multipart.Part) error {
+	rd.files++
+	if rd.opt.maxFiles > 0 && rd.files > rd.opt.maxFiles {
+		return fmt.Errorf(
+			"body must not contain more than %d files", rd.opt.maxFiles,
+		)
+	}
+	var src io.Reader = part
+	if rd.opt.maxFileSize > 0 {
+		// One extra byte tells whether the file is too large.
+		src = io.LimitReader(part, rd.opt.maxFileSize+1)
+	}
+
+	// The type is checked before reading the rest of the file.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) &&
+		!errors.Is(err, io.EOF) {
+		return multipartError(err)
+	}
+	head = head[:n]
+	contentType, err := rd.opt.checkType(name, head)
+	if err != nil {
+		return err
+	}
+
+	f := &File{
+		Filename:    part.FileName(),
+		ContentType: contentType,
+		Header:      part.Header,
+	}
+	src = io.MultiReader(bytes.NewReader(head), src)
+	var buf bytes.Buffer
+	size, err := io.CopyN(&buf, src, rd.memoryLeft+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return multipartError(err)
+	}
+	if size <= rd.memoryLeft {
+		rd.memoryLeft -= size
+		f.content = buf.Bytes()
+	} else {
+		// The file doesn't fit in memory, so it is stored on disk.
+		tmp, err := os.CreateTemp("", "multipart-")
+		if err != nil {
+			return fmt.Errorf("unable to store file %q: %w", name, err)
+		}
+		rd.paths = append(rd.paths, tmp.Name())
+		size, err = io.Copy(tmp, io.MultiReader(&buf, src))
+		if closeErr := tmp.Close(); err == nil && closeErr != nil {
+			return fmt.Errorf("unable to store file %q: %w", name, closeErr)
+		}
+		if err != nil {
+			return multipartError(err)
+		}
+		f.path = tmp.Name()
+	}
+	if rd.opt.maxFileSize > 0 && size > rd.opt.maxFileSize {
+		return fmt.Errorf(
+			"file %q must not be larger than %d bytes",
+			name, rd.opt.maxFileSize,
+		)
+	}
+	f.Size = size
+	rd.m.Files[name] = append(rd.m.Files[name], f)
+	return nil
+}
+
+// removeAll removes the files stored on disk.
+func (rd *multipartReader) removeAll() {
+	for _, path := range rd.paths {
+		_ = os.Remove(path)
+	}
+}
+
+// checkType detects the media type of the file from its first 512 bytes, and
+// checks whether it is allowed.
+func (o *multipartOption) checkType(name string, head []byte) (string, error) {
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", fmt.Errorf("unable to read file %q: %w", name, err)
+	}
+	if len(o.allowedTypes) != 0 && !slices.ContainsFunc(
+		o.allowedTypes,
+		func(allowed string) bool {
+			return contentType == allowed ||
+				strings.HasSuffix(allowed, "/") &&
+					strings.HasPrefix(contentType, allowed)
+		},
+	) {
+		return "", fmt.Errorf(
+			"file %q has type %q, which is not allowed", name, contentType,
+		)
+	}
+	return contentType, nil
+}
+
+// multipartError translates the errors of parsing a multipart body into
+// human-readable ones.
+func multipartError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesError):
+		return fmt.Errorf(
+			"body must not be larger than %d bytes", maxBytesError.Limit,
+		)
+	case errors.Is(err, multipart.ErrMessageTooLarge):
+		return errors.New("body contains too large form fields")
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("body contains badly-formed multipart data")
+	default:
+		return fmt.Errorf("unable to parse body: %w", err)
+	}
+}
@@ -0,0 +1,195 @@
+package grape
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func multipartRequest(
+	t *testing.T, fields map[string]string, files map[string][]byte,
+) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := mw.WriteField(name, value); err != nil {
+			t.Fatalf("write field: %v", err)
+		}
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+		if _, err := fw.Write(content); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestReadMultipart_Success(t *testing.T) {
+	req := multipartRequest(
+		t,
+		map[string]string{"title": "grape"},
+		map[string][]byte{"avatar": append(pngHeader, "rest"...)},
+	)
+
+	form, err := ReadMultipart(
+		httptest.NewRecorder(), req, WithAllowedFileTypes("image/"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := form.Value("title"); got != "grape" {
+		t.Fatalf("expected title %q, got %q", "grape", got)
+	}
+	avatar := form.File("avatar")
+	if avatar == nil {
+		t.Fatal("expected avatar file")
+	}
+	if avatar.ContentType != "image/png" || avatar.Filename != "avatar.bin" {
+		t.Fatalf("unexpected file: %+v", avatar)
+	}
+	f, err := avatar.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	content, _ := io.ReadAll(f)
+	if int64(len(content)) != avatar.Size {
+		t.Fatalf("expected %d bytes, got %d", avatar.Size, len(content))
+	}
+}
+
+func TestReadMultipart_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		opts  []MultipartOpts
+		want  string
+	}{
+		{
+			name:  "file too large",
+			files: map[string][]byte{"doc": bytes.Repeat([]byte("a"), 100)},
+			opts:  []MultipartOpts{WithMaxFileSize(10)},
+			want:  `file "doc" must not be larger than 10 bytes`,
+		},
+		{
+			name:  "body too large",
+			files: map[string][]byte{"doc": bytes.Repeat([]byte("a"), 1000)},
+			opts:  []MultipartOpts{WithMaxUploadSize(100)},
+			want:  "body must not be larger than 100 bytes",
+		},
+		{
+			name:  "too many files",
+			files: map[string][]byte{"a": []byte("a"), "b": []byte("b")},
+			opts:  []MultipartOpts{WithMaxFiles(1)},
+			want:  "body must not contain more than 1 files",
+		},
+		{
+			name: "sniffed type not allowed",
+			// The client's claimed type doesn't matter.
+			files: map[string][]byte{"avatar": []byte("<html>hello</html>")},
+			opts:  []MultipartOpts{WithAllowedFileTypes("image/png")},
+			want:  `file "avatar" has type "text/html", which is not allowed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := multipartRequest(t, nil, tt.files)
+			_, err := ReadMultipart(httptest.NewRecorder(), req, tt.opts...)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReadMultipart_NotMultipart(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := ReadMultipart(httptest.NewRecorder(), req)
+	if err == nil || !strings.Contains(err.Error(), "multipart/form-data") {
+		t.Fatalf("expected content type error, got %v", err)
+	}
+}
+
+func TestReadMultipart_StopsAtFirstViolation(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("huge", "huge.bin")
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	fw.Write(bytes.Repeat([]byte("a"), 10<<20))
+	mw.Close()
+
+	body := &countingReader{r: &buf}
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	_, err = ReadMultipart(httptest.NewRecorder(), req, WithMaxFileSize(1<<10))
+
+	want := `file "huge" must not be larger than 1024 bytes`
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+	if body.n > 64<<10 {
+		t.Fatalf("expected body not to be read in full, read %d bytes", body.n)
+	}
+}
+
+func TestReadMultipart_StoresOnDisk(t *testing.T) {
+	content := append(pngHeader, bytes.Repeat([]byte("a"), 100)...)
+	req := multipartRequest(t, nil, map[string][]byte{"avatar": content})
+	ctx, cancel := context.WithCancel(req.Context())
+	req = req.WithContext(ctx)
+
+	form, err := ReadMultipart(
+		httptest.NewRecorder(), req, WithMemoryLimit(10),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	avatar := form.File("avatar")
+	if avatar.path == "" || avatar.ContentType != "image/png" {
+		t.Fatalf("expected file on disk, got %+v", avatar)
+	}
+	f, err := avatar.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got, _ := io.ReadAll(f)
+	f.Close()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("unexpected content: %q", got)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(avatar.path); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected file to be removed once the request is done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
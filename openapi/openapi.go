@@ -0,0 +1,225 @@
+// Package openapi generates OpenAPI 3.1 documents from the routes registered on
+// a [grape.Router]. Operations can be enriched with summaries, tags, and Go
+// types which are reflected into JSON Schema.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/hossein1376/grape"
+)
+
+const version = "3.1.0"
+
+// Spec holds the general information of the API, alongside the metadata of its
+// operations. Routes are read from the router each time a document is
+// generated, so there is no need to describe every one of them.
+type Spec struct {
+	info       info
+	operations map[string][]Options
+}
+
+// New returns a new instance of [Spec] with the given title and version of the
+// API.
+func New(title, version string) *Spec {
+	return &Spec{
+		info:       info{Title: title, Version: version},
+		operations: make(map[string][]Options),
+	}
+}
+
+// Describe attaches metadata to the operation identified by the method and the
+// full pattern of a route, as reported by [grape.Router.Routes].
+//
+// Example:
+//
+//	spec.Describe(
+//		http.MethodGet,
+//		"/users/{id}",
+//		openapi.WithSummary("Get user by ID"),
+//		openapi.WithResponse[User](http.StatusOK),
+//	)
+func (s *Spec) Describe(method, pattern string, opts ...Options) {
+	key := method + " " + pattern
+	s.operations[key] = append(s.operations[key], opts...)
+}
+
+// Generate builds the OpenAPI document from the routes of the given router,
+// and returns it in JSON format.
+func (s *Spec) Generate(router *grape.Router) ([]byte, error) {
+	doc := document{
+		OpenAPI: version,
+		Info:    s.info,
+		Paths:   make(map[string]map[string]*operation),
+	}
+	schemas := newSchemaBuilder()
+
+	for _, route := range router.Routes() {
+		if route.Method == "" {
+			continue
+		}
+		path, params := parsePattern(route.Pattern)
+		op := s.operation(route, params, schemas)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	if len(schemas.components) != 0 {
+		doc.Components = &components{Schemas: schemas.components}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Handler returns an [http.HandlerFunc] which serves the generated document.
+func (s *Spec) Handler(router *grape.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		js, err := s.Generate(router)
+		if err != nil {
+			grape.ExtractFromErr(r.Context(), w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(js)
+	}
+}
+
+// WriteFile generates the document and writes it to the named file, creating
+// it if necessary.
+func (s *Spec) WriteFile(router *grape.Router, name string) error {
+	js, err := s.Generate(router)
+	if err != nil {
+		return fmt.Errorf("generate document: %w", err)
+	}
+	return os.WriteFile(name, js, 0o644)
+}
+
+// operation builds the operation of the route. Its name and tags, set by
+// [grape.WithRouteName] and [grape.WithRouteTags], are used as the defaults of
+// the operation ID and tags.
+func (s *Spec) operation(
+	route grape.Route, params []parameter, schemas *schemaBuilder,
+) *operation {
+	op := &operation{
+		OperationID:   route.Name,
+		Tags:          slices.Clone(route.Tags),
+		Parameters:    params,
+		Responses:     make(map[string]*response),
+		responseTypes: make(map[int]reflect.Type),
+	}
+	for _, o := range s.operations[route.Method+" "+route.Pattern] {
+		o(op)
+	}
+
+	if op.requestType != nil {
+		op.RequestBody = &requestBody{
+			Required: true,
+			Content:  jsonContent(schemas.schemaOf(op.requestType)),
+		}
+	}
+	for _, code := range slices.Sorted(maps.Keys(op.responseTypes)) {
+		resp := &response{Description: statusDescription(code)}
+		if t := op.responseTypes[code]; t != nil {
+			resp.Content = jsonContent(schemas.schemaOf(t))
+		}
+		op.Responses[statusKey(code)] = resp
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &response{Description: "Default response"}
+	}
+	return op
+}
+
+// parsePattern converts a [http.ServeMux] pattern into an OpenAPI path, and
+// extracts its path parameters. Wildcards such as {name...} are reduced to
+// {name}, and the {$} anchor is removed.
+func parsePattern(pattern string) (string, []parameter) {
+	// Patterns may be prefixed with a host, which is not part of the path.
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+
+	var params []parameter
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(seg[1:len(seg)-1], "...")
+		if name == "$" {
+			segments[i] = ""
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &schema{Type: "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func jsonContent(s *schema) map[string]mediaType {
+	return map[string]mediaType{"application/json": {Schema: s}}
+}
+
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components *components                      `json:"components,omitempty"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+
+	requestType   reflect.Type
+	responseTypes map[int]reflect.Type
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type components struct {
+	Schemas map[string]*schema `json:"schemas"`
+}
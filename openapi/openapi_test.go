@@ -0,0 +1,229 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hossein1376/grape"
+)
+
+type user struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Friends   []user    `json:"friends,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	secret    string
+}
+
+type createUser struct {
+	Name string `json:"name"`
+}
+
+func TestSpec_Generate(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r := grape.NewRouter()
+	r.Get("/users/{id}", noop)
+	v1 := r.Group("/v1")
+	v1.Post("/users", noop)
+	v1.Get("/files/{path...}", noop)
+
+	spec := New("Users", "1.0.0")
+	spec.Describe(
+		http.MethodGet,
+		"/users/{id}",
+		WithSummary("Get user"),
+		WithTags("users"),
+		WithResponse[user](http.StatusOK),
+	)
+	spec.Describe(
+		http.MethodPost,
+		"/v1/users",
+		WithRequest[createUser](),
+		WithResponse[*user](http.StatusCreated),
+		WithEmptyResponse(http.StatusConflict),
+	)
+
+	js, err := spec.Generate(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal(js, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Users" {
+		t.Fatalf("unexpected document header: %+v", doc)
+	}
+	get := doc.Paths["/users/{id}"]["get"]
+	if get == nil {
+		t.Fatalf("missing get operation: %v", doc.Paths)
+	}
+	if get.Summary != "Get user" || !slices.Equal(get.Tags, []string{"users"}) {
+		t.Fatalf("unexpected operation metadata: %+v", get)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" ||
+		get.Parameters[0].In != "path" {
+		t.Fatalf("unexpected parameters: %+v", get.Parameters)
+	}
+	ref := get.Responses["200"].Content["application/json"].Schema.Ref
+	if ref != "#/components/schemas/user" {
+		t.Fatalf("unexpected response schema ref: %q", ref)
+	}
+
+	post := doc.Paths["/v1/users"]["post"]
+	if post == nil || post.RequestBody == nil {
+		t.Fatalf("missing post request body: %+v", post)
+	}
+	if _, ok := post.Responses["409"]; !ok {
+		t.Fatalf("missing empty response: %+v", post.Responses)
+	}
+	if _, ok := doc.Paths["/v1/files/{path}"]["get"]; !ok {
+		t.Fatalf("wildcard path not converted: %v", doc.Paths)
+	}
+
+	s := doc.Components.Schemas["user"]
+	if s == nil {
+		t.Fatalf("missing user component: %v", doc.Components.Schemas)
+	}
+	if _, ok := s.Properties["secret"]; ok {
+		t.Fatalf("unexported field must not be documented")
+	}
+	if s.Properties["friends"].Items.Ref != "#/components/schemas/user" {
+		t.Fatalf("unexpected recursive schema: %+v", s.Properties["friends"])
+	}
+	if s.Properties["created_at"].Format != "date-time" {
+		t.Fatalf("unexpected time schema: %+v", s.Properties["created_at"])
+	}
+	if !slices.Equal(s.Required, []string{"id", "name", "created_at"}) {
+		t.Fatalf("unexpected required fields: %v", s.Required)
+	}
+}
+
+func TestSpec_GenerateRouteDefaults(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r := grape.NewRouter()
+	r.Get(
+		"/users",
+		noop,
+		grape.WithRouteName("listUsers"),
+		grape.WithRouteTags("users"),
+	)
+	r.Post(
+		"/users",
+		noop,
+		grape.WithRouteName("createUser"),
+		grape.WithRouteTags("users"),
+	)
+
+	spec := New("Users", "1.0.0")
+	spec.Describe(
+		http.MethodPost,
+		"/users",
+		WithOperationID("addUser"),
+		WithTags("admin"),
+	)
+
+	js, err := spec.Generate(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal(js, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	get := doc.Paths["/users"]["get"]
+	if get.OperationID != "listUsers" ||
+		!slices.Equal(get.Tags, []string{"users"}) {
+		t.Fatalf("route defaults not used: %+v", get)
+	}
+	post := doc.Paths["/users"]["post"]
+	if post.OperationID != "addUser" ||
+		!slices.Equal(post.Tags, []string{"users", "admin"}) {
+		t.Fatalf("options not applied over route defaults: %+v", post)
+	}
+}
+
+func TestSpec_Handler(t *testing.T) {
+	r := grape.NewRouter()
+	spec := New("API", "0.1.0")
+	r.Get("/openapi.json", spec.Handler(r))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var doc document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := doc.Paths["/openapi.json"]["get"]; !ok {
+		t.Fatalf("expected spec route to be documented: %v", doc.Paths)
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  []string
+	}{
+		{pattern: "/", path: "/"},
+		{pattern: "/{$}", path: "/"},
+		{pattern: "/a/{b}/c/{d...}", path: "/a/{b}/c/{d}", params: []string{"b", "d"}},
+		{pattern: "example.com/x/{id}", path: "/x/{id}", params: []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			path, params := parsePattern(tt.pattern)
+			if path != tt.path {
+				t.Errorf("path = %q, want %q", path, tt.path)
+			}
+			var names []string
+			for _, p := range params {
+				names = append(names, p.Name)
+			}
+			if !slices.Equal(names, tt.params) {
+				t.Errorf("params = %v, want %v", names, tt.params)
+			}
+		})
+	}
+}
+
+func TestSchemaBuilder_NameCollision(t *testing.T) {
+	first := reflect.TypeFor[user]()
+	second := func() reflect.Type {
+		type user struct {
+			Role string `json:"role"`
+		}
+		return reflect.TypeFor[user]()
+	}()
+
+	b := newSchemaBuilder()
+	refs := []string{
+		b.schemaOf(first).Ref,
+		b.schemaOf(second).Ref,
+		b.schemaOf(first).Ref,
+	}
+	want := []string{
+		"#/components/schemas/user",
+		"#/components/schemas/user_2",
+		"#/components/schemas/user",
+	}
+	if !slices.Equal(refs, want) {
+		t.Fatalf("expected refs %v, got %v", want, refs)
+	}
+	if _, ok := b.components["user_2"].Properties["role"]; !ok {
+		t.Fatalf("unexpected schema: %+v", b.components["user_2"])
+	}
+}
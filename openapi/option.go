@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+type Options func(*operation)
+
+// WithSummary sets a short summary of what the operation does.
+func WithSummary(summary string) Options {
+	return func(o *operation) {
+		o.Summary = summary
+	}
+}
+
+// WithDescription sets a verbose explanation of the operation behaviour.
+func WithDescription(description string) Options {
+	return func(o *operation) {
+		o.Description = description
+	}
+}
+
+// WithOperationID sets a unique identifier for the operation. It is commonly
+// used by code generators to name client methods. By default, the route's name
+// is used.
+func WithOperationID(id string) Options {
+	return func(o *operation) {
+		o.OperationID = id
+	}
+}
+
+// WithTags adds tags to the operation, which are used for logical grouping. They
+// are added to the route's own tags.
+func WithTags(tags ...string) Options {
+	return func(o *operation) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// WithRequest declares type T as the JSON request body of the operation. Its
+// JSON Schema is derived from the type using reflection.
+func WithRequest[T any]() Options {
+	return func(o *operation) {
+		o.requestType = reflect.TypeFor[T]()
+	}
+}
+
+// WithResponse declares type T as the JSON response body for the given status
+// code. It can be provided multiple times for different status codes.
+func WithResponse[T any](statusCode int) Options {
+	return func(o *operation) {
+		o.responseTypes[statusCode] = reflect.TypeFor[T]()
+	}
+}
+
+// WithEmptyResponse declares a response without a body for the given status
+// code, such as [http.StatusNoContent].
+func WithEmptyResponse(statusCode int) Options {
+	return func(o *operation) {
+		o.responseTypes[statusCode] = nil
+	}
+}
+
+func statusKey(statusCode int) string {
+	return strconv.Itoa(statusCode)
+}
+
+func statusDescription(statusCode int) string {
+	if text := http.StatusText(statusCode); text != "" {
+		return text
+	}
+	return "Response"
+}
@@ -0,0 +1,180 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+const componentsRefPrefix = "#/components/schemas/"
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schemaBuilder reflects Go types into JSON Schema. Named struct types are
+// collected as reusable components and referenced by their name.
+type schemaBuilder struct {
+	components map[string]*schema
+	// names holds the component name of each type. Distinct types with the
+	// same name, such as those from different packages, get a suffix.
+	names map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		components: make(map[string]*schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+func (b *schemaBuilder) schemaOf(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case t.Implements(textMarshalerType),
+		reflect.PointerTo(t).Implements(textMarshalerType):
+		return &schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &schema{Type: "integer", Format: "int64", Minimum: new(int)}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32", Minimum: new(int)}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{
+			Type:                 "object",
+			AdditionalProperties: b.schemaOf(t.Elem()),
+		}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = b.componentName(t)
+			// Register a placeholder first, so that recursive types
+			// resolve to a reference instead of looping forever.
+			b.names[t] = name
+			b.components[name] = &schema{}
+			b.components[name] = b.structSchema(t)
+		}
+		return &schema{Ref: componentsRefPrefix + name}
+	default:
+		// Interfaces and other kinds accept any value.
+		return &schema{}
+	}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	b.addFields(s, t)
+	return s
+}
+
+func (b *schemaBuilder) addFields(s *schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs have their fields promoted, the same
+		// way encoding/json treats them.
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(s, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = b.schemaOf(field.Type)
+		if hasOption(opts, "string") {
+			s.Properties[name] = &schema{Type: "string"}
+		}
+		if !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") &&
+			field.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for o := range strings.SplitSeq(opts, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// componentName returns an unused component name for the type, replacing
+// characters which are not allowed in component keys, such as those of generic
+// types. If the name is already taken by another type, a numeric suffix is
+// added.
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9',
+			r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, t.Name())
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := b.components[name]; !ok {
+			return name
+		}
+		name = base + "_" + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,76 @@
+package grape
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+
+	"github.com/hossein1376/grape/errs"
+	"github.com/hossein1376/grape/reqid"
+	"github.com/hossein1376/grape/validator"
+)
+
+type problemCtx string
+
+const problemInstance problemCtx = "problem_instance"
+
+// ProblemDetailsMiddleware makes [ExtractFromErr] write errors in the
+// application/problem+json format, as described in RFC 9457. The request's
+// path is used as the instance member of the problem.
+//
+// The members are populated from [errs.Error]: type from Type, detail from
+// Message, and status and title from HTTPStatusCode. Its extensions, the
+// request ID, and the fields of a wrapped [validator.ValidationError] are added
+// as extension members. Other errors are written as 500 responses, without
+// exposing their details.
+func ProblemDetailsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), problemInstance, r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func writeProblem(
+	ctx context.Context,
+	w http.ResponseWriter,
+	e errs.Error,
+	err error,
+	instance string,
+) {
+	extensions := e.Extensions()
+	body := make(Map, len(extensions)+6)
+	maps.Copy(body, extensions)
+
+	if reqID, ok := reqid.RequestID(ctx); ok {
+		body["request_id"] = reqID
+	}
+	var verrs validator.ValidationError
+	if errors.As(err, &verrs) {
+		body["errors"] = verrs
+	}
+
+	// Standard members are set last, so extensions can't override them.
+	body["type"] = e.Type
+	if e.Type == "" {
+		body["type"] = "about:blank"
+	}
+	body["title"] = http.StatusText(e.HTTPStatusCode)
+	body["status"] = e.HTTPStatusCode
+	if e.Message != "" {
+		body["detail"] = e.Message
+	}
+	if instance != "" {
+		body["instance"] = instance
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/problem+json")
+	WriteJSON(
+		ctx,
+		w,
+		WithStatus(e.HTTPStatusCode),
+		WithData(body),
+		WithHeaders(headers),
+	)
+}
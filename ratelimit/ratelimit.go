@@ -0,0 +1,211 @@
+// Package ratelimit provides a token bucket rate limiting middleware. Buckets
+// are identified by a key derived from each request, such as the client's IP,
+// and are kept in a pluggable [Store].
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hossein1376/grape"
+	"github.com/hossein1376/grape/errs"
+	"github.com/hossein1376/grape/slogger"
+)
+
+// Limit allows Requests per Period on average, with bursts of up to Burst
+// requests. If Burst is not positive, it defaults to Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// PerSecond returns a [Limit] of n requests per second.
+func PerSecond(n int) Limit {
+	return Limit{Requests: n, Period: time.Second}
+}
+
+// PerMinute returns a [Limit] of n requests per minute.
+func PerMinute(n int) Limit {
+	return Limit{Requests: n, Period: time.Minute}
+}
+
+// PerHour returns a [Limit] of n requests per hour.
+func PerHour(n int) Limit {
+	return Limit{Requests: n, Period: time.Hour}
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate returns the number of tokens added to the bucket each second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// refill returns the duration an empty bucket needs to be full again.
+func (l Limit) refill() time.Duration {
+	return l.Period * time.Duration(l.burst()) / time.Duration(l.Requests)
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Limit is the capacity of the bucket.
+	Limit int
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// Reset is the duration until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the duration until a token is available, if the request
+	// is not allowed.
+	RetryAfter time.Duration
+}
+
+// KeyFunc derives the bucket key from the request. If it returns false, the
+// request is not rate limited.
+type KeyFunc func(r *http.Request) (string, bool)
+
+// ByIP uses the client's IP address, taken from the connection's remote
+// address, as the key. Behind a reverse proxy, use [ByHeader] instead.
+func ByIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr, r.RemoteAddr != ""
+	}
+	return host, true
+}
+
+// ByHeader uses the value of the given header as the key, such as the
+// X-Real-Ip header set by a trusted reverse proxy. Requests without the header
+// are not rate limited.
+func ByHeader(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		v := r.Header.Get(name)
+		return v, v != ""
+	}
+}
+
+// BySubject uses the authenticated subject as the key, which is extracted from
+// the request's context by the provided function; usually populated by an
+// authentication middleware. Anonymous requests are not rate limited.
+func BySubject(subject func(ctx context.Context) (string, bool)) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		return subject(r.Context())
+	}
+}
+
+type option struct {
+	key   KeyFunc
+	store Store
+	name  string
+}
+
+type Options func(*option)
+
+// WithKey sets the function deriving the bucket key. By default, [ByIP] is
+// used.
+func WithKey(key KeyFunc) Options {
+	return func(o *option) {
+		o.key = key
+	}
+}
+
+// WithStore sets the store keeping the buckets. By default, each middleware
+// has its own [MemoryStore].
+func WithStore(store Store) Options {
+	return func(o *option) {
+		o.store = store
+	}
+}
+
+// WithName prefixes the keys with the given name. It must be unique between
+// middlewares sharing the same store, so their buckets don't collide.
+func WithName(name string) Options {
+	return func(o *option) {
+		o.name = name
+	}
+}
+
+var instances atomic.Uint64
+
+// New returns a middleware limiting the requests to the given limit. It can be
+// applied to a group via [grape.Router.Use], or wrapped around a single
+// handler. Each call creates a separate set of buckets. It panics if the
+// limit's Requests or Period is not positive.
+//
+// Every response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. Rejected requests are responded by
+// [grape.ExtractFromErr] with [errs.TooMany], and a Retry-After header. If the
+// store fails, the error is logged and the request is allowed.
+//
+// Example:
+//
+//	api := r.Group("/api")
+//	api.Use(ratelimit.New(ratelimit.PerMinute(100)))
+//
+//	login := ratelimit.New(
+//		ratelimit.Limit{Requests: 5, Period: time.Minute, Burst: 10},
+//		ratelimit.WithKey(ratelimit.ByHeader("X-Real-Ip")),
+//	)
+//	r.Post("/login", login(loginHandler).ServeHTTP)
+func New(limit Limit, opts ...Options) func(http.Handler) http.Handler {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		panic("ratelimit: limit must have positive requests and period")
+	}
+	opt := &option{key: ByIP}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.store == nil {
+		// Idle buckets must not be evicted before they are refilled, or
+		// they would come back full.
+		opt.store = NewMemoryStore(max(defaultIdleTTL, limit.refill()))
+	}
+	if opt.name == "" {
+		opt.name = strconv.FormatUint(instances.Add(1), 10)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			key, ok := opt.key(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := opt.store.Take(ctx, opt.name+":"+key, limit)
+			if err != nil {
+				slogger.Error(ctx, "rate limit store", slogger.Err("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				grape.ExtractFromErr(ctx, w, errs.TooMany())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestNew_LimitsPerKey(t *testing.T) {
+	h := New(Limit{Requests: 1, Period: time.Minute, Burst: 2})(okHandler())
+
+	for i := range 2 {
+		rec := serve(h, "10.0.0.1:1234")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status 200, got %d", i, rec.Code)
+		}
+	}
+	rec := serve(h, "10.0.0.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", rec.Code)
+	}
+	hdr := rec.Header()
+	if hdr.Get("RateLimit-Limit") != "2" || hdr.Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected rate limit headers: %v", hdr)
+	}
+	if hdr.Get("Retry-After") != "60" || hdr.Get("RateLimit-Reset") != "120" {
+		t.Fatalf("unexpected retry headers: %v", hdr)
+	}
+
+	// A different client has its own bucket.
+	if rec := serve(h, "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("expected other client to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestNew_BySubject(t *testing.T) {
+	type subjectKey struct{}
+	h := New(
+		PerHour(1),
+		WithKey(BySubject(func(ctx context.Context) (string, bool) {
+			s, ok := ctx.Value(subjectKey{}).(string)
+			return s, ok
+		})),
+	)(okHandler())
+
+	// Anonymous requests are not limited.
+	for range 3 {
+		if rec := serve(h, "10.0.0.1:1"); rec.Code != http.StatusOK {
+			t.Fatalf("expected anonymous request to pass, got %d", rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), subjectKey{}, "alice"))
+	codes := make([]int, 2)
+	for i := range codes {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		codes[i] = rec.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status codes: %v", codes)
+	}
+}
+
+func TestMemoryStore_RefillAndEviction(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore(time.Minute)
+	s.now = func() time.Time { return now }
+	limit := PerSecond(1)
+	ctx := context.Background()
+
+	if res, _ := s.Take(ctx, "k", limit); !res.Allowed {
+		t.Fatalf("expected first request to be allowed")
+	}
+	if res, _ := s.Take(ctx, "k", limit); res.Allowed {
+		t.Fatalf("expected second request to be denied")
+	} else if res.RetryAfter != time.Second {
+		t.Fatalf("unexpected retry after: %v", res.RetryAfter)
+	}
+
+	now = now.Add(time.Second)
+	if res, _ := s.Take(ctx, "k", limit); !res.Allowed {
+		t.Fatalf("expected request to be allowed after refill")
+	}
+
+	now = now.Add(2 * time.Minute)
+	s.Take(ctx, "other", limit)
+	if n := s.Len(); n != 1 {
+		t.Fatalf("expected idle bucket to be evicted, got %d buckets", n)
+	}
+}
+
+func TestNew_InvalidLimit(t *testing.T) {
+	for _, limit := range []Limit{PerSecond(0), {Requests: 1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic for limit %+v", limit)
+				}
+			}()
+			New(limit)
+		}()
+	}
+}
+
+func TestLimit_Refill(t *testing.T) {
+	limit := Limit{Requests: 10, Period: time.Hour, Burst: 20}
+	if got := limit.refill(); got != 2*time.Hour {
+		t.Fatalf("expected refill of 2h, got %v", got)
+	}
+	if got := PerHour(5).refill(); got != time.Hour {
+		t.Fatalf("expected refill of 1h, got %v", got)
+	}
+}
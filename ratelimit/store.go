@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	shardCount     = 32
+	defaultIdleTTL = 10 * time.Minute
+)
+
+// Store keeps the state of the token buckets. Implementations must be safe for
+// concurrent use. It allows for a shared backend to be used between multiple
+// instances of the application.
+type Store interface {
+	// Take attempts to take a single token from the bucket identified by the
+	// key, creating it if necessary.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryStore is an in-memory [Store]. Buckets are spread between shards to
+// reduce lock contention, and are evicted after being idle for a while.
+type MemoryStore struct {
+	seed      maphash.Seed
+	idleTTL   time.Duration
+	now       func() time.Time
+	lastSweep atomic.Int64
+	shards    [shardCount]shard
+}
+
+type shard struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryStore returns a new instance of [MemoryStore]. Buckets which have
+// not been used for the idle TTL are evicted; it should be longer than the
+// time a bucket needs to be refilled. A non-positive value defaults to ten
+// minutes.
+func NewMemoryStore(idleTTL time.Duration) *MemoryStore {
+	if idleTTL <= 0 {
+		idleTTL = defaultIdleTTL
+	}
+	s := &MemoryStore{
+		seed:    maphash.MakeSeed(),
+		idleTTL: idleTTL,
+		now:     time.Now,
+	}
+	s.lastSweep.Store(s.now().UnixNano())
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]*bucket)
+	}
+	return s
+}
+
+// Take implements the [Store] interface.
+func (s *MemoryStore) Take(
+	_ context.Context, key string, limit Limit,
+) (Result, error) {
+	now := s.now()
+	s.evict(now)
+
+	sh := &s.shards[maphash.String(s.seed, key)%shardCount]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	capacity := float64(limit.burst())
+	b, ok := sh.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		sh.buckets[key] = b
+	}
+
+	rate := limit.rate()
+	b.tokens = min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	res := Result{Limit: limit.burst()}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = seconds((capacity - b.tokens) / rate)
+	return res, nil
+}
+
+// evict removes the idle buckets. It is done lazily, at most once per idle TTL,
+// by the first caller after it has passed.
+func (s *MemoryStore) evict(now time.Time) {
+	last := s.lastSweep.Load()
+	if now.UnixNano()-last < int64(s.idleTTL) ||
+		!s.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for k, b := range sh.buckets {
+			if now.Sub(b.last) > s.idleTTL {
+				delete(sh.buckets, k)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// Len returns the number of buckets currently held by the store.
+func (s *MemoryStore) Len() int {
+	var n int
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].buckets)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
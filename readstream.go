@@ -0,0 +1,218 @@
+package grape
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+)
+
+const defaultMaxStreamSize = 64 << 20 // 64mb
+
+// elementSlack is read past the element size limit of JSON arrays, to allow for
+// the separators and whitespace around the elements.
+const elementSlack = 4 << 10 // 4kb
+
+var errElementTooLarge = errors.New("element is too large")
+
+// ElementError is returned by [ReadJSONStream] when an element can't be
+// decoded or fails its validation.
+type ElementError struct {
+	Index int
+	Err   error
+}
+
+func (e ElementError) Error() string {
+	return fmt.Sprintf("element %d: %s", e.Index, e.Err)
+}
+
+func (e ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ReadJSONStream returns an iterator over the elements of the request's body,
+// decoding them one at a time; so the whole body is never held in memory. The
+// body must be a top-level JSON array if the content type is application/json,
+// or newline-delimited JSON if it is application/x-ndjson.
+//
+// Each element is decoded as described in [ReadJSON], and its Validate method
+// is called if it is implemented. Failing elements are yielded as an
+// [ElementError] carrying their zero-based index, and the iteration continues;
+// callers may stop it by breaking out of the loop. Errors of the stream itself,
+// such as malformed JSON or exceeding the total size, end the iteration.
+//
+// By default, the body must not be larger than 64MB, and each element must not
+// be larger than 1MB. They can be changed with [WithMaxTotalSize] and
+// [WithMaxElementSize] options. Elements are never buffered far beyond their
+// limit; those which can't be skipped without doing so end the iteration. The
+// returned iterator may only be used once.
+//
+// Example:
+//
+//	for user, err := range grape.ReadJSONStream[User](w, r) {
+//		if err != nil {
+//			grape.ExtractFromErr(ctx, w, errs.BadRequest(errs.WithErrMsg(err)))
+//			return
+//		}
+//		// import the user
+//	}
+func ReadJSONStream[T any](
+	w http.ResponseWriter, r *http.Request, opts ...ReadOpts[T],
+) iter.Seq2[T, error] {
+	opt := &readOptions[T]{
+		maxBodySize:    defaultMaxStreamSize,
+		maxElementSize: defaultMaxBodySize,
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		body := http.MaxBytesReader(w, r.Body, opt.maxBodySize)
+		switch mediaType {
+		case "application/json":
+			readJSONArray(body, opt, yield)
+		case "application/x-ndjson":
+			readNDJSON(body, opt, yield)
+		default:
+			yield(zero, errors.New(
+				"content type is not application/json or application/x-ndjson",
+			))
+		}
+	}
+}
+
+func readJSONArray[T any](
+	body io.Reader, opt *readOptions[T], yield func(T, error) bool,
+) {
+	var zero T
+	lr := &limitedReader{r: body, limit: -1}
+	dec := json.NewDecoder(lr)
+	tok, err := dec.Token()
+	if err != nil {
+		yield(zero, jsonError(err))
+		return
+	}
+	if tok != json.Delim('[') {
+		yield(zero, errors.New("body must be a JSON array"))
+		return
+	}
+
+	for i := 0; ; i++ {
+		// Bound what the decoder may buffer while reading the element.
+		lr.limit = dec.InputOffset() + opt.maxElementSize + elementSlack
+		if !dec.More() {
+			break
+		}
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		switch {
+		case errors.Is(err, errElementTooLarge):
+			yield(zero, ElementError{Index: i, Err: fmt.Errorf(
+				"must not be larger than %d bytes", opt.maxElementSize,
+			)})
+			return
+		case err != nil:
+			yield(zero, jsonError(err))
+			return
+		}
+		if !yield(decodeElement(i, raw, opt)) {
+			return
+		}
+	}
+	lr.limit = -1
+	if _, err := dec.Token(); err != nil {
+		yield(zero, jsonError(err))
+		return
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		yield(zero, errors.New("body must only contain a single JSON value"))
+	}
+}
+
+func readNDJSON[T any](
+	body io.Reader, opt *readOptions[T], yield func(T, error) bool,
+) {
+	var zero T
+	scanner := bufio.NewScanner(body)
+	// One extra byte is allowed for the line break.
+	scanner.Buffer(nil, int(opt.maxElementSize)+1)
+	var i int
+	for scanner.Scan() {
+		// The remaining data is returned as the last line upon read errors,
+		// which is incomplete.
+		if scanner.Err() != nil {
+			break
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !yield(decodeElement(i, line, opt)) {
+			return
+		}
+		i++
+	}
+
+	err := scanner.Err()
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case err == nil:
+	case errors.Is(err, bufio.ErrTooLong):
+		yield(zero, ElementError{Index: i, Err: fmt.Errorf(
+			"must not be larger than %d bytes", opt.maxElementSize,
+		)})
+	case errors.As(err, &maxBytesError):
+		yield(zero, fmt.Errorf(
+			"body must not be larger than %d bytes", maxBytesError.Limit,
+		))
+	default:
+		yield(zero, fmt.Errorf("unable to read body: %w", err))
+	}
+}
+
+// limitedReader fails once limit bytes in total are read from r, unless limit
+// is negative.
+type limitedReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.limit >= 0 {
+		if l.read >= l.limit {
+			return 0, errElementTooLarge
+		}
+		p = p[:min(int64(len(p)), l.limit-l.read)]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// decodeElement decodes and validates a single element of the stream.
+func decodeElement[T any](
+	i int, raw []byte, opt *readOptions[T],
+) (T, error) {
+	var v T
+	if int64(len(raw)) > opt.maxElementSize {
+		return v, ElementError{Index: i, Err: fmt.Errorf(
+			"must not be larger than %d bytes", opt.maxElementSize,
+		)}
+	}
+	if err := JSONCodec.Decode(bytes.NewReader(raw), &v); err != nil {
+		return v, ElementError{Index: i, Err: err}
+	}
+	if err := validate(&v); err != nil {
+		return v, ElementError{Index: i, Err: err}
+	}
+	return v, nil
+}
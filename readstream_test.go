@@ -0,0 +1,180 @@
+package grape
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+func (s streamItem) Validate() error {
+	if s.ID <= 0 {
+		return errors.New("id must be positive")
+	}
+	return nil
+}
+
+func readStream(
+	t *testing.T, contentType, body string, opts ...ReadOpts[streamItem],
+) ([]int, []error) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+
+	var ids []int
+	var errs []error
+	for item, err := range ReadJSONStream(httptest.NewRecorder(), req, opts...) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids, errs
+}
+
+func TestReadJSONStream_Formats(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "application/json", body: ` [{"id":1}, {"id":2} ,{"id":3}] `},
+		{
+			contentType: "application/x-ndjson",
+			body:        "{\"id\":1}\n{\"id\":2}\r\n\n{\"id\":3}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			ids, errs := readStream(t, tt.contentType, tt.body)
+			if len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+				t.Fatalf("unexpected ids: %v", ids)
+			}
+		})
+	}
+}
+
+func TestReadJSONStream_ElementErrors(t *testing.T) {
+	for _, ct := range []string{"application/json", "application/x-ndjson"} {
+		t.Run(ct, func(t *testing.T) {
+			items := []string{
+				`{"id":1}`, `{"id":0}`, `{"id":"x"}`, `{"id":4,"padding":"xxxxxxxxxx"}`,
+			}
+			body := "[" + strings.Join(items, ",") + "]"
+			if ct == "application/x-ndjson" {
+				body = strings.Join(items, "\n")
+			}
+
+			ids, errs := readStream(
+				t, ct, body, WithMaxElementSize[streamItem](20),
+			)
+			if len(ids) != 1 || ids[0] != 1 {
+				t.Fatalf("unexpected ids: %v", ids)
+			}
+			if len(errs) != 3 {
+				t.Fatalf("expected 3 errors, got %v", errs)
+			}
+			for i, err := range errs {
+				var e ElementError
+				if !errors.As(err, &e) || e.Index != i+1 {
+					t.Fatalf("expected error of element %d, got %v", i+1, err)
+				}
+			}
+		})
+	}
+}
+
+func TestReadJSONStream_StreamErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []ReadOpts[streamItem]
+		want        string
+	}{
+		{
+			name:        "not an array",
+			contentType: "application/json",
+			body:        `{"id":1}`,
+			want:        "body must be a JSON array",
+		},
+		{
+			name:        "unterminated",
+			contentType: "application/json",
+			body:        `[{"id":1},`,
+			want:        "body contains badly-formed JSON",
+		},
+		{
+			name:        "trailing value",
+			contentType: "application/json",
+			body:        `[{"id":1}] []`,
+			want:        "body must only contain a single JSON value",
+		},
+		{
+			name:        "too large",
+			contentType: "application/x-ndjson",
+			body:        strings.Repeat("{\"id\":1}\n", 10),
+			opts:        []ReadOpts[streamItem]{WithMaxTotalSize[streamItem](30)},
+			want:        "body must not be larger than 30 bytes",
+		},
+		{
+			name:        "unsupported",
+			contentType: "text/plain",
+			want:        "content type is not",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := readStream(t, tt.contentType, tt.body, tt.opts...)
+			if len(errs) != 1 {
+				t.Fatalf("expected a single error, got %v", errs)
+			}
+			if !strings.Contains(errs[0].Error(), tt.want) {
+				t.Fatalf("expected error %q, got %q", tt.want, errs[0])
+			}
+		})
+	}
+}
+
+func TestReadJSONStream_LargeElementNotBuffered(t *testing.T) {
+	body := &countingReader{r: strings.NewReader(
+		`[{"id":1},"` + strings.Repeat("x", 1<<20) + `",{"id":3}]`,
+	)}
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", "application/json")
+
+	var errs []error
+	opt := WithMaxElementSize[streamItem](20)
+	for _, err := range ReadJSONStream(httptest.NewRecorder(), req, opt) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var e ElementError
+	if len(errs) != 1 || !errors.As(errs[0], &e) || e.Index != 1 {
+		t.Fatalf("expected a single error of element 1, got %v", errs)
+	}
+	if body.n > 64<<10 {
+		t.Fatalf("expected element to be rejected early, read %d bytes", body.n)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
@@ -17,31 +17,44 @@ type Response struct {
 }
 
 // Respond is a general function which responses with the provided message
-// and status code. It acts as an abstraction over WriteJson.
+// and status code. It acts as an abstraction over [Write]. Additional options
+// are passed to it as well; such as [WithRequest], to pick the encoding based
+// on the Accept header of the request.
 func Respond(
-	ctx context.Context, w http.ResponseWriter, statusCode int, data any,
+	ctx context.Context,
+	w http.ResponseWriter,
+	statusCode int,
+	data any,
+	opts ...WriteOpts,
 ) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	opts := []WriteOpts{WithStatus(statusCode)}
+	opts = append([]WriteOpts{WithStatus(statusCode)}, opts...)
 	if data != nil {
 		opts = append(opts, WithData(data))
 	}
 
-	WriteJSON(ctx, w, opts...)
+	Write(ctx, w, opts...)
 }
 
 // ExtractFromErr extracts a response from the given error. If nil, 204 response
 // is returned. If error is of type [errs.Error], the status code and response
 // message are filled accordingly. Otherwise, a 500 response with the request ID
 // are returned.
+//
+// If the request has passed through [ProblemDetailsMiddleware], errors are
+// written as RFC 9457 problem details instead.
 func ExtractFromErr(ctx context.Context, w http.ResponseWriter, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if err == nil {
 		Respond(ctx, w, http.StatusNoContent, nil)
 		return
 	}
+	instance, problem := ctx.Value(problemInstance).(string)
 
 	var e errs.Error
 	if errors.As(err, &e) {
@@ -49,7 +62,11 @@ func ExtractFromErr(ctx context.Context, w http.ResponseWriter, err error) {
 		if msg == "" {
 			msg = http.StatusText(e.HTTPStatusCode)
 		}
-		Respond(ctx, w, e.HTTPStatusCode, Response{Message: msg})
+		if problem {
+			writeProblem(ctx, w, e, err, instance)
+		} else {
+			Respond(ctx, w, e.HTTPStatusCode, Response{Message: msg})
+		}
 		slogger.Debug(
 			ctx,
 			"failed request",
@@ -61,6 +78,10 @@ func ExtractFromErr(ctx context.Context, w http.ResponseWriter, err error) {
 	}
 
 	slogger.Error(ctx, "internal error", slogger.Err("error", err))
+	if problem {
+		writeProblem(ctx, w, errs.Internal(), err, instance)
+		return
+	}
 	reqID, _ := reqid.RequestID(ctx)
 	Respond(
 		ctx,
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/hossein1376/grape/errs"
 	"github.com/hossein1376/grape/reqid"
+	"github.com/hossein1376/grape/validator"
 )
 
 // TestRespondWritesJSON verifies Respond writes JSON body and headers when data
@@ -149,3 +151,100 @@ func TestExtractFromErrGenericErrorIncludesReqID(t *testing.T) {
 		t.Fatalf("expected Data to equal request id, got %v", r.Data)
 	}
 }
+
+// TestExtractFromErrProblemDetails verifies that errors are written as RFC 9457
+// problem details once the request has passed through the middleware.
+func TestExtractFromErrProblemDetails(t *testing.T) {
+	verrs := validator.ValidationError{"name": {"is required"}}
+	handler := ProblemDetailsMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(
+				r.Context(), reqid.RequestIDKey, reqid.ReqID("req-1"),
+			)
+			ExtractFromErr(ctx, w, errs.BadRequest(
+				errs.WithErr(verrs),
+				errs.WithMsg("invalid input"),
+				errs.WithType("https://example.com/probs/invalid"),
+				errs.WithExtension("balance", 30),
+				errs.WithExtension("status", 999),
+			))
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d got %d", http.StatusBadRequest, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("unexpected content-type %q", ct)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	want := map[string]any{
+		"type":       "https://example.com/probs/invalid",
+		"title":      http.StatusText(http.StatusBadRequest),
+		"status":     float64(http.StatusBadRequest),
+		"detail":     "invalid input",
+		"instance":   "/users",
+		"request_id": "req-1",
+		"balance":    float64(30),
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("member %q: got %v want %v", k, body[k], v)
+		}
+	}
+	fields, ok := body["errors"].(map[string]any)
+	if !ok || fields["name"] == nil {
+		t.Errorf("expected validation errors, got %v", body["errors"])
+	}
+}
+
+// TestExtractFromErrProblemDetailsInternal verifies that generic errors are
+// written as problem details without exposing their message.
+func TestExtractFromErrProblemDetailsInternal(t *testing.T) {
+	handler := ProblemDetailsMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ExtractFromErr(r.Context(), w, errors.New("db password leaked"))
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500 got %d", rec.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if body["type"] != "about:blank" || body["detail"] != nil {
+		t.Fatalf("unexpected problem body: %v", body)
+	}
+}
+
+// TestErrsComparable verifies that errs.Error values can still be matched by
+// errors.Is, with or without extensions.
+func TestErrsComparable(t *testing.T) {
+	err := fmt.Errorf("get user: %w", errs.NotFound())
+	if !errors.Is(err, errs.NotFound()) {
+		t.Fatalf("expected errors.Is to match errs.NotFound")
+	}
+
+	sentinel := errs.Conflict(errs.WithExtension("balance", 30))
+	err = fmt.Errorf("transfer: %w", sentinel)
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("expected errors.Is to match the sentinel with extensions")
+	}
+	if errors.Is(err, errs.Conflict(errs.WithExtension("balance", 30))) {
+		t.Fatalf("expected distinct extensions not to match")
+	}
+	if got := sentinel.Extensions()["balance"]; got != 30 {
+		t.Fatalf("unexpected extension: %v", got)
+	}
+}
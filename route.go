@@ -0,0 +1,195 @@
+package grape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type routeCtx string
+
+const routeKey routeCtx = "route"
+
+type routeOption struct {
+	middlewares []func(http.Handler) http.Handler
+	name        string
+	tags        []string
+	timeout     time.Duration
+	metadata    map[string]any
+}
+
+type RouteOpts func(*routeOption)
+
+// WithRouteMiddlewares adds middlewares to a single route. They run after the
+// middlewares of the router's scope, in the order given.
+func WithRouteMiddlewares(
+	middlewares ...func(http.Handler) http.Handler,
+) RouteOpts {
+	return func(o *routeOption) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithRouteName sets the name of the route, which identifies it among all the
+// routes of the router. Paths of named routes are built by [Router.URL]. If the
+// name is already taken, it stays with the first route, and the clash is
+// reported by [Router.Build].
+func WithRouteName(name string) RouteOpts {
+	return func(o *routeOption) {
+		o.name = name
+	}
+}
+
+// WithRouteTags adds tags to the route, such as the groups it is documented
+// under. The openapi package uses them as the tags of the route's operation.
+func WithRouteTags(tags ...string) RouteOpts {
+	return func(o *routeOption) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithRouteTimeout sets a deadline on the request's context, before any of the
+// route's middlewares run. It is up to the handler to respect the context.
+func WithRouteTimeout(timeout time.Duration) RouteOpts {
+	return func(o *routeOption) {
+		o.timeout = timeout
+	}
+}
+
+// WithRouteMetadata attaches an arbitrary value to the route, which can be
+// read by middlewares via [RouteFromContext].
+func WithRouteMetadata(key string, value any) RouteOpts {
+	return func(o *routeOption) {
+		if o.metadata == nil {
+			o.metadata = make(map[string]any)
+		}
+		o.metadata[key] = value
+	}
+}
+
+// RouteFromContext returns the matched route of the request. It is available to
+// the handler, and all the middlewares added by [Router.Use] or
+// [WithRouteMiddlewares]; but not to the ones added by [Router.UseAll], which
+// run before the route is matched.
+//
+// Example:
+//
+//	func requirePermission(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			route, _ := grape.RouteFromContext(r.Context())
+//			permission, _ := route.Metadata["permission"].(string)
+//			if !hasPermission(r, permission) {
+//				grape.ExtractFromErr(r.Context(), w, errs.Forbidden())
+//				return
+//			}
+//			next.ServeHTTP(w, r)
+//		})
+//	}
+func RouteFromContext(ctx context.Context) (Route, bool) {
+	route, ok := ctx.Value(routeKey).(Route)
+	if !ok {
+		return Route{}, false
+	}
+	return route.clone(), true
+}
+
+// URL builds the path of the named route, filling its wildcards with the
+// params, in order. Values are formatted by [fmt.Sprint] and escaped; the value
+// of a "{name...}" wildcard may contain slashes, and each of its segments is
+// escaped separately. The scope of the route is included.
+//
+// Example:
+//
+//	r.Get("/users/{id}/files/{path...}", getFile, grape.WithRouteName("file"))
+//
+//	r.URL("file", 42, "docs/q1 report.pdf")
+//	// "/users/42/files/docs/q1%20report.pdf"
+func (r *Router) URL(name string, params ...any) (string, error) {
+	if name == "" {
+		return "", errors.New("route name must not be empty")
+	}
+	r.root.mu.Lock()
+	route, found := r.root.names[name]
+	r.root.mu.Unlock()
+	if !found {
+		return "", fmt.Errorf("route %q not found", name)
+	}
+
+	pattern := route.Pattern
+	// The host of the pattern, if any, is not part of the path.
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	var b strings.Builder
+	var n int
+	for {
+		start := strings.IndexByte(pattern, '{')
+		end := strings.IndexByte(pattern, '}')
+		if start == -1 || end < start {
+			b.WriteString(pattern)
+			break
+		}
+		b.WriteString(pattern[:start])
+		wildcard := pattern[start+1 : end]
+		pattern = pattern[end+1:]
+		if wildcard == "$" {
+			continue
+		}
+
+		wildcard, multi := strings.CutSuffix(wildcard, "...")
+		if n == len(params) {
+			return "", fmt.Errorf(
+				"route %q: missing value of %q", name, wildcard,
+			)
+		}
+		value := fmt.Sprint(params[n])
+		n++
+		if !multi {
+			if value == "" {
+				return "", fmt.Errorf(
+					"route %q: empty value of %q", name, wildcard,
+				)
+			}
+			b.WriteString(url.PathEscape(value))
+			continue
+		}
+		segments := strings.Split(value, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		b.WriteString(strings.Join(segments, "/"))
+	}
+	if n != len(params) {
+		return "", fmt.Errorf(
+			"route %q: expected %d params, got %d", name, n, len(params),
+		)
+	}
+	return b.String(), nil
+}
+
+// withRoute stores the route in the request's context, and applies its
+// timeout.
+func withRoute(route Route, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeKey, route)
+		if route.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, route.Timeout)
+			defer cancel()
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clone returns a copy of the route, which doesn't share its tags and metadata.
+func (r Route) clone() Route {
+	r.Tags = slices.Clone(r.Tags)
+	r.Metadata = maps.Clone(r.Metadata)
+	return r
+}
@@ -1,24 +1,105 @@
 package grape
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"regexp"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/hossein1376/grape/errs"
+	"github.com/hossein1376/grape/slogger"
 )
 
 // Router provides methods such as [Router.Get], [Router.Post], and [Router.Use]
 // (among others) for routing.
 type Router struct {
-	scope       string
-	routes      map[string]http.Handler
-	middlewares []func(http.Handler) http.Handler
-	root        *root
+	scope            string
+	routes           map[string]http.Handler
+	details          map[string]Route
+	order            map[string]uint64
+	middlewares      []func(http.Handler) http.Handler
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+	root             *root
+}
+
+// Route describes an endpoint registered on the [Router]. It is a read-only
+// snapshot; modifying it has no effect on the router.
+type Route struct {
+	// Method is the HTTP method the route responds to. It is empty for the
+	// routes added by [Router.Mount], which respond to all methods.
+	Method string
+	// Pattern is the full path pattern, including the group's scope.
+	Pattern string
+	// Scope is the prefix of the group the route was registered on.
+	Scope string
+	// Middlewares is the number of middlewares wrapped around the handler,
+	// from both the scope and [WithRouteMiddlewares]. Global middlewares,
+	// added by [Router.UseAll], are not counted.
+	Middlewares int
+	// Name is the unique name of the route, set by [WithRouteName].
+	Name string
+	// Tags are set by [WithRouteTags].
+	Tags []string
+	// Timeout is the deadline of the request's context, set by
+	// [WithRouteTimeout].
+	Timeout time.Duration
+	// Metadata holds arbitrary values, set by [WithRouteMetadata].
+	Metadata map[string]any
 }
 
 type root struct {
+	// mu guards the registration of routes and middlewares, across all the
+	// instances of Router. buildMu serializes building the handler.
+	mu      sync.Mutex
+	buildMu sync.Mutex
 	global  []func(http.Handler) http.Handler
 	routes  map[string]*Router
-	handler http.Handler
+	// names holds the named routes, keyed by their name.
+	names map[string]Route
+	// handler is the last handler successfully built from the routes, and
+	// stale reports whether they have changed since the last build.
+	handler atomic.Pointer[http.Handler]
+	stale   atomic.Bool
+	// buildErr is the error of the last build.
+	buildErr error
+	// registered counts the registrations, ordering the routes.
+	registered uint64
+	// errs holds the problems found while registering the routes, which are
+	// reported by [Router.Build]. They don't prevent serving the routes.
+	errs []error
+}
+
+// RouteError describes a route which could not be registered. It is returned
+// by [Router.Build], joined with the other problems.
+type RouteError struct {
+	Route Route
+	Err   error
+}
+
+func (e RouteError) Error() string {
+	return fmt.Sprintf("route %s: %v", describeRoute(e.Route), e.Err)
+}
+
+// describeRoute returns the method, pattern and scope of the route.
+func describeRoute(route Route) string {
+	method := route.Method
+	if method == "" {
+		method = "*"
+	}
+	return fmt.Sprintf("%s %s in scope %q", method, route.Pattern, route.Scope)
+}
+
+func (e RouteError) Unwrap() error {
+	return e.Err
 }
 
 // NewRouter will initialize and returns a new router. This function is expected
@@ -26,26 +107,106 @@ type root struct {
 // via the [Router.Group] method.
 func NewRouter() *Router {
 	rt := &Router{
-		routes: make(map[string]http.Handler),
+		routes:  make(map[string]http.Handler),
+		details: make(map[string]Route),
+		order:   make(map[string]uint64),
 		root: &root{
 			global: make([]func(http.Handler) http.Handler, 0),
 			routes: make(map[string]*Router),
+			names:  make(map[string]Route),
 		},
 	}
 	rt.root.routes[""] = rt
+	rt.root.stale.Store(true)
 	return rt
 }
 
+// ServeHTTP implements [http.Handler]. The routes are built upon the first
+// request, and rebuilt after any later registration; so routes can be added
+// while serving. If rebuilding fails, the error is logged and the previously
+// built routes keep being served. It panics only if the routes have never been
+// valid; call [Router.Build] at startup to catch such problems early.
 func (r *Router) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	if r.root.handler == nil {
-		r.root.handler = r.newHandler()
+	h := r.root.handler.Load()
+	if h == nil || r.root.stale.Load() {
+		var err error
+		if h, err = r.build(); h == nil {
+			panic(err)
+		}
+	}
+	(*h).ServeHTTP(writer, request)
+}
+
+// Build validates all the routes, and returns the handler serving them. It
+// makes no difference on which instance of Router this method is called from.
+// Malformed patterns, conflicting patterns across all groups, and duplicate
+// route names are reported together; each as a [RouteError]. Registering the
+// same method and pattern again is not an error; it replaces the previous
+// route, and a warning is logged.
+//
+// Otherwise, the handler is built lazily upon the first request, and panics if
+// the routes have never been valid. Calling Build at startup, or in tests, fails fast instead. It
+// may also be called after adding routes while serving, to find out whether
+// they are in effect. The returned handler is the router itself.
+//
+// Example:
+//
+//	r := newRouter()
+//	if _, err := r.Build(); err != nil {
+//		log.Fatal(err)
+//	}
+func (r *Router) Build() (http.Handler, error) {
+	if _, err := r.build(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// build rebuilds the handler if the routes have changed since. It returns the
+// last handler which was built successfully, if any, along with the problems
+// of the routes.
+func (r *Router) build() (*http.Handler, error) {
+	// Builds are serialized, while the registrations may go on; root.mu is
+	// released while the global middlewares are applied, so they may call
+	// back into the router.
+	r.root.buildMu.Lock()
+	defer r.root.buildMu.Unlock()
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+
+	// Another request might have built it in the meantime.
+	if r.root.stale.Load() {
+		// Registrations from now on mark it as stale again.
+		r.root.stale.Store(false)
+		h, err := r.newHandler()
+		global := r.root.global
+
+		r.root.mu.Unlock()
+		if err == nil {
+			h = withMiddlewares(h, global)
+			r.root.handler.Store(&h)
+		}
+		r.root.mu.Lock()
+
+		r.root.buildErr = err
+		if err != nil && r.root.handler.Load() != nil {
+			slogger.Error(
+				context.Background(),
+				"build routes, serving the previous ones",
+				slogger.Err("error", err),
+			)
+		}
 	}
-	r.root.handler.ServeHTTP(writer, request)
+	problems := append(slices.Clone(r.root.errs), r.root.buildErr)
+	return r.root.handler.Load(), errors.Join(problems...)
 }
 
 // Group creates a new Router instance from the current one, inheriting scope
 // and middlewares.
 func (r *Router) Group(prefix string) *Router {
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+
 	newScope := r.scope + prefix
 	existing, ok := r.root.routes[newScope]
 	if ok {
@@ -54,43 +215,230 @@ func (r *Router) Group(prefix string) *Router {
 	newRouter := &Router{
 		scope:       newScope,
 		routes:      make(map[string]http.Handler),
+		details:     make(map[string]Route),
+		order:       make(map[string]uint64),
 		middlewares: slices.Clone(r.middlewares),
 		root:        r.root,
 	}
 
 	r.root.routes[newScope] = newRouter
+	r.root.stale.Store(true)
 	return newRouter
 }
 
 // Get calls [Method] with the [http.MethodGet] method.
-func (r *Router) Get(route string, handler http.HandlerFunc) {
-	r.Method(http.MethodGet, route, handler)
+func (r *Router) Get(
+	route string, handler http.HandlerFunc, opts ...RouteOpts,
+) {
+	r.Method(http.MethodGet, route, handler, opts...)
 }
 
 // Post calls [Method] with the [http.MethodPost] method.
-func (r *Router) Post(route string, handler http.HandlerFunc) {
-	r.Method(http.MethodPost, route, handler)
+func (r *Router) Post(
+	route string, handler http.HandlerFunc, opts ...RouteOpts,
+) {
+	r.Method(http.MethodPost, route, handler, opts...)
 }
 
 // Put calls [Method] with the [http.MethodPut] method.
-func (r *Router) Put(route string, handler http.HandlerFunc) {
-	r.Method(http.MethodPut, route, handler)
+func (r *Router) Put(
+	route string, handler http.HandlerFunc, opts ...RouteOpts,
+) {
+	r.Method(http.MethodPut, route, handler, opts...)
 }
 
 // Patch calls [Method] with the [http.MethodPatch] method.
-func (r *Router) Patch(route string, handler http.HandlerFunc) {
-	r.Method(http.MethodPatch, route, handler)
+func (r *Router) Patch(
+	route string, handler http.HandlerFunc, opts ...RouteOpts,
+) {
+	r.Method(http.MethodPatch, route, handler, opts...)
 }
 
 // Delete calls [Method] with the [http.MethodDelete] method.
-func (r *Router) Delete(route string, handler http.HandlerFunc) {
-	r.Method(http.MethodDelete, route, handler)
+func (r *Router) Delete(
+	route string, handler http.HandlerFunc, opts ...RouteOpts,
+) {
+	r.Method(http.MethodDelete, route, handler, opts...)
+}
+
+// Method accepts an http method, a single route, and one handler. Options may
+// add middlewares to this route alone, or describe it.
+//
+// Example:
+//
+//	r.Delete(
+//		"/users/{id}",
+//		deleteUser,
+//		grape.WithRouteName("delete-user"),
+//		grape.WithRouteMiddlewares(requirePermission),
+//		grape.WithRouteMetadata("permission", "users:delete"),
+//		grape.WithRouteTimeout(5*time.Second),
+//	)
+func (r *Router) Method(
+	method, route string, handler http.HandlerFunc, opts ...RouteOpts,
+) {
+	r.register(method, route, handler, opts...)
+}
+
+type mountOption struct {
+	stripPrefix bool
+}
+
+type MountOpts func(*mountOption)
+
+// WithStripPrefix removes the full prefix of the mount, including the group's
+// scope, from the request's path before it reaches the handler.
+func WithStripPrefix() MountOpts {
+	return func(o *mountOption) {
+		o.stripPrefix = true
+	}
+}
+
+// Mount routes all the requests under the prefix to the handler, regardless of
+// their method. The handler is wrapped by the group's middlewares, and its
+// [Route] has an empty Method. The request's path is passed as is, unless the
+// [WithStripPrefix] option is provided.
+//
+// More specific routes, registered on the same router, take precedence. The
+// mount is registered for each of the standard methods, and the ones used by
+// other routes; so it can live next to a catch-all route such as the one of
+// [Router.Static]. Requests with any other method don't reach the handler.
+//
+// Example:
+//
+//	r.Mount("/debug/pprof", http.HandlerFunc(pprof.Index))
+//	r.Mount("/billing", billing.NewRouter(), grape.WithStripPrefix())
+func (r *Router) Mount(prefix string, handler http.Handler, opts ...MountOpts) {
+	opt := &mountOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if opt.stripPrefix {
+		handler = http.StripPrefix(r.scope+prefix, handler)
+	}
+	r.register("", prefix+"/", handler)
 }
 
-// Method accepts an http method, a single route, and one handler.
-func (r *Router) Method(method, route string, handler http.HandlerFunc) {
+// register adds the handler, wrapped by the middlewares, to the routes. An
+// empty method matches all the methods.
+func (r *Router) register(
+	method, route string, handler http.Handler, opts ...RouteOpts,
+) {
+	opt := &routeOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	// Middlewares are applied without holding the lock, so they may call
+	// back into the router.
+	r.root.mu.Lock()
+	middlewares := r.middlewares
+	r.root.mu.Unlock()
+	// Route middlewares run after the scope ones, in the order given.
+	for _, middleware := range slices.Backward(opt.middlewares) {
+		handler = middleware(handler)
+	}
+	handler = withMiddlewares(handler, middlewares)
+
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+	r.root.stale.Store(true)
+
 	rt := r.root.routes[r.scope]
-	rt.routes[method+" "+r.scope+route] = r.withMiddlewares(handler)
+	key := r.scope + route
+	if method != "" {
+		key = method + " " + key
+	}
+	if previous, ok := rt.details[key]; ok {
+		// The last registration wins, as it always has.
+		slogger.Warn(
+			context.Background(),
+			"route registered multiple times, replacing the previous one",
+			slog.String("route", key),
+		)
+		if previous.Name != "" {
+			delete(r.root.names, previous.Name)
+		}
+	}
+	details := Route{
+		Method:      method,
+		Pattern:     r.scope + route,
+		Scope:       r.scope,
+		Middlewares: len(middlewares) + len(opt.middlewares),
+		Name:        opt.name,
+		Tags:        opt.tags,
+		Timeout:     opt.timeout,
+		Metadata:    opt.metadata,
+	}
+	if details.Name != "" {
+		// The name keeps referring to the route it was first given to.
+		if other, ok := r.root.names[details.Name]; ok {
+			r.root.errs = append(r.root.errs, RouteError{
+				Route: details,
+				Err: fmt.Errorf(
+					"name %q is already used by %s",
+					details.Name, other.Pattern,
+				),
+			})
+			details.Name = ""
+		} else {
+			r.root.names[details.Name] = details
+		}
+	}
+
+	rt.routes[key] = withRoute(details, handler)
+	rt.details[key] = details
+	r.root.registered++
+	rt.order[key] = r.root.registered
+}
+
+// Routes returns all the registered routes, regardless of which instance of
+// Router it is called from. Routes are sorted by their pattern, and then by
+// their method.
+func (r *Router) Routes() []Route {
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+	return r.sortedRoutes()
+}
+
+// sortedRoutes is the same as [Router.Routes]. r.root.mu must be held.
+func (r *Router) sortedRoutes() []Route {
+	var routes []Route
+	for _, rt := range r.root.routes {
+		for _, route := range rt.details {
+			routes = append(routes, route.clone())
+		}
+	}
+	slices.SortFunc(routes, func(a, b Route) int {
+		return cmp.Or(
+			cmp.Compare(a.Pattern, b.Pattern), cmp.Compare(a.Method, b.Method),
+		)
+	})
+	return routes
+}
+
+// NotFound sets the handler of the requests under the router's scope which
+// don't match any route. Groups without their own handler inherit it from the
+// closest parent scope. By default, [errs.NotFound] is responded by
+// [ExtractFromErr].
+func (r *Router) NotFound(handler http.HandlerFunc) {
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+	r.root.stale.Store(true)
+	r.root.routes[r.scope].notFound = handler
+}
+
+// MethodNotAllowed sets the handler of the requests under the router's scope
+// whose path matches a route, but not their method. The Allow header, listing
+// the matching methods, is set before calling it. Groups without their own
+// handler inherit it from the closest parent scope. By default,
+// [errs.MethodNotAllowed] is responded by [ExtractFromErr].
+func (r *Router) MethodNotAllowed(handler http.HandlerFunc) {
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+	r.root.stale.Store(true)
+	r.root.routes[r.scope].methodNotAllowed = handler
 }
 
 // Use adds middlewares to the routes that are defined **after** it.
@@ -105,6 +453,8 @@ func (r *Router) Use(middlewares ...func(http.Handler) http.Handler) {
 	//
 	// Another approach is to reverse middlewares before applying them.
 
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
 	slices.Reverse(middlewares)
 	r.middlewares = slices.Concat(middlewares, r.middlewares)
 }
@@ -117,6 +467,9 @@ func (r *Router) Use(middlewares ...func(http.Handler) http.Handler) {
 // scope and path.
 func (r *Router) UseAll(middlewares ...func(http.Handler) http.Handler) {
 	// Refer to [Use] method for documentation.
+	r.root.mu.Lock()
+	defer r.root.mu.Unlock()
+	r.root.stale.Store(true)
 	slices.Reverse(middlewares)
 	r.root.global = slices.Concat(middlewares, r.root.global)
 }
@@ -124,11 +477,22 @@ func (r *Router) UseAll(middlewares ...func(http.Handler) http.Handler) {
 // Serve will start the server on the provided address. It makes no difference
 // on which instance of Router this method is called from.
 // A nil value for server is valid. The two fields [Addr] and [Handler] of
-// [http.Server] are populated by the function itself.
+// [http.Server] are populated by the function itself. The routes are validated
+// by [Router.Build] before the server starts.
 func (r *Router) Serve(addr string, server *http.Server) error {
-	h := r.root.handler
-	if h == nil {
-		h = r.newHandler()
+	server, err := r.newServer(addr, server)
+	if err != nil {
+		return err
+	}
+	return server.ListenAndServe()
+}
+
+func (r *Router) newServer(
+	addr string, server *http.Server,
+) (*http.Server, error) {
+	h, err := r.Build()
+	if err != nil {
+		return nil, err
 	}
 	if server == nil {
 		server = &http.Server{
@@ -140,26 +504,187 @@ func (r *Router) Serve(addr string, server *http.Server) error {
 	}
 	server.Addr = addr
 	server.Handler = h
-	return server.ListenAndServe()
+	return server, nil
 }
 
-func (r *Router) newHandler() http.Handler {
+// newHandler builds the handler from the routes, without the global
+// middlewares. r.root.mu must be held.
+func (r *Router) newHandler() (http.Handler, error) {
 	mux := http.NewServeMux()
-	for _, rt := range r.root.routes {
-		for path, handle := range rt.routes {
-			mux.Handle(path, handle)
+	methods := []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodConnect,
+		http.MethodOptions,
+		http.MethodTrace,
+	}
+	// Routes are added in the order they were registered, so the later ones
+	// are reported on conflicts.
+	routes := r.sortedRoutes()
+	keyOf := func(route Route) string {
+		if route.Method == "" {
+			return route.Pattern
+		}
+		return route.Method + " " + route.Pattern
+	}
+	slices.SortStableFunc(routes, func(a, b Route) int {
+		return cmp.Compare(
+			r.root.routes[a.Scope].order[keyOf(a)],
+			r.root.routes[b.Scope].order[keyOf(b)],
+		)
+	})
+
+	for _, route := range routes {
+		if route.Method != "" && !slices.Contains(methods, route.Method) {
+			methods = append(methods, route.Method)
+		}
+	}
+
+	var problems []error
+	registered := make(map[string]Route, len(routes))
+	for _, route := range routes {
+		key := keyOf(route)
+		handler := r.root.routes[route.Scope].routes[key]
+		// Mounts are registered for each method, since method-less patterns
+		// conflict with the more general patterns of a single method; such
+		// as the one of [Router.Static]. HEAD is covered by GET.
+		patterns := []string{key}
+		if route.Method == "" {
+			patterns = patterns[:0]
+			for _, method := range methods {
+				if method != http.MethodHead {
+					patterns = append(patterns, method+" "+route.Pattern)
+				}
+			}
+		}
+		for _, pattern := range patterns {
+			if err := handle(mux, pattern, handler, registered); err != nil {
+				problems = append(problems, RouteError{Route: route, Err: err})
+				break
+			}
+			registered[pattern] = route
+		}
+	}
+	if len(problems) != 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	notFound := make(map[string]http.Handler)
+	methodNotAllowed := make(map[string]http.Handler)
+	for scope, rt := range r.root.routes {
+		if rt.notFound != nil {
+			notFound[scope] = rt.notFound
+		}
+		if rt.methodNotAllowed != nil {
+			methodNotAllowed[scope] = rt.methodNotAllowed
+		}
+	}
+
+	var h http.Handler = http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if _, pattern := mux.Handler(req); pattern != "" {
+				mux.ServeHTTP(w, req)
+				return
+			}
+			if allow := allowedMethods(mux, req, methods); len(allow) != 0 {
+				w.Header().Set("Allow", strings.Join(allow, ", "))
+				fallback(methodNotAllowed, req, errs.MethodNotAllowed()).
+					ServeHTTP(w, req)
+				return
+			}
+			fallback(notFound, req, errs.NotFound()).ServeHTTP(w, req)
+		},
+	)
+	return h, nil
+}
+
+// handle registers the handler on the mux, recovering from its panics on
+// invalid or conflicting patterns. Conflicts are described by the other route,
+// looked up by its pattern in registered, instead of the mux's own locations.
+func handle(
+	mux *http.ServeMux,
+	pattern string,
+	handler http.Handler,
+	registered map[string]Route,
+) (err error) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		msg := fmt.Sprint(rec)
+		if m := conflictPattern.FindStringSubmatch(msg); m != nil {
+			if other, ok := registered[m[1]]; ok {
+				err = fmt.Errorf(
+					"conflicts with route %s: %s", describeRoute(other), m[2],
+				)
+				return
+			}
+		}
+		err = errors.New(registeredAt.ReplaceAllString(msg, ""))
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+var (
+	// conflictPattern matches the panics of [http.ServeMux] on conflicting
+	// patterns, capturing the other pattern and the description.
+	conflictPattern = regexp.MustCompile(
+		`(?s)conflicts with pattern "(.*?)" \(registered at [^)]*\):\n(.*)$`,
+	)
+	registeredAt = regexp.MustCompile(` \(registered at [^)]*\)`)
+)
+
+// allowedMethods returns the methods which match the request's path, by
+// probing the mux with each of them.
+func allowedMethods(
+	mux *http.ServeMux, req *http.Request, methods []string,
+) []string {
+	var allow []string
+	probe := req.Clone(req.Context())
+	for _, method := range methods {
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			allow = append(allow, method)
 		}
 	}
+	return allow
+}
 
-	var h http.Handler = mux
-	for _, middleware := range r.root.global {
-		h = middleware(h)
+// fallback returns the handler of the closest scope to the request's path,
+// among the given handlers keyed by their scope. If no scope has one, the error
+// is responded.
+func fallback(
+	handlers map[string]http.Handler, req *http.Request, err error,
+) http.Handler {
+	var handler http.Handler
+	var scope string
+	for s, h := range handlers {
+		if handler != nil && len(s) <= len(scope) {
+			continue
+		}
+		if s == "" || req.URL.Path == s ||
+			strings.HasPrefix(req.URL.Path, strings.TrimSuffix(s, "/")+"/") {
+			handler, scope = h, s
+		}
+	}
+	if handler != nil {
+		return handler
 	}
-	return h
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ExtractFromErr(req.Context(), w, err)
+	})
 }
 
-func (r *Router) withMiddlewares(handler http.Handler) http.Handler {
-	for _, middleware := range r.middlewares {
+func withMiddlewares(
+	handler http.Handler, middlewares []func(http.Handler) http.Handler,
+) http.Handler {
+	for _, middleware := range middlewares {
 		handler = middleware(handler)
 	}
 	return handler
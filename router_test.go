@@ -1,10 +1,18 @@
 package grape
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 // helper middleware generator that appends markers before and after calling next
@@ -190,3 +198,436 @@ func TestRouter_UseAllPrecedence(t *testing.T) {
 		}
 	}
 }
+
+// Test that Routes reports every registered route across groups, sorted by
+// pattern and method, alongside its scope and middleware count.
+func TestRouter_Routes(t *testing.T) {
+	r := NewRouter()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	r.Post("/users", noop)
+	r.Get("/users", noop)
+
+	v1 := r.Group("/v1")
+	v1.Use(markerMiddleware("m1"), markerMiddleware("m2"))
+	v1.Delete("/items/{id}", noop)
+
+	got := v1.Routes()
+	want := []Route{
+		{Method: http.MethodGet, Pattern: "/users", Scope: ""},
+		{Method: http.MethodPost, Pattern: "/users", Scope: ""},
+		{
+			Method:      http.MethodDelete,
+			Pattern:     "/v1/items/{id}",
+			Scope:       "/v1",
+			Middlewares: 2,
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected routes length: got %v want %v", got, want)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("unexpected route at %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	r := NewRouter()
+	echoPath := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+
+	v1 := r.Group("/v1")
+	v1.Use(markerMiddleware("m1"))
+	v1.Mount("/raw", echoPath)
+	v1.Mount("/stripped/", echoPath, WithStripPrefix())
+	v1.Get("/raw/special", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("special"))
+	})
+
+	tests := []struct {
+		method string
+		target string
+		want   string
+	}{
+		{method: http.MethodGet, target: "/v1/raw/a/b", want: "GET /v1/raw/a/b"},
+		{method: http.MethodPatch, target: "/v1/raw/", want: "PATCH /v1/raw/"},
+		{method: http.MethodPost, target: "/v1/stripped/x", want: "POST /x"},
+		{method: http.MethodGet, target: "/v1/raw/special", want: "special"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if got := rec.Body.String(); got != tt.want {
+				t.Fatalf("expected body %q, got %q", tt.want, got)
+			}
+			if got := rec.Header().Values("X-Order"); len(got) != 2 {
+				t.Fatalf("expected the group's middleware, got %v", got)
+			}
+		})
+	}
+
+	routes := r.Routes()
+	if routes[0].Method != "" || routes[0].Pattern != "/v1/raw/" {
+		t.Fatalf("unexpected mount route: %+v", routes[0])
+	}
+}
+
+func TestRouter_MountNextToStatic(t *testing.T) {
+	r := NewRouter()
+	r.Static("/", fstest.MapFS{"index.html": {Data: []byte("spa")}})
+	r.Mount("/debug", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("debug " + r.Method))
+	}))
+	if _, err := r.Build(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		target string
+		want   string
+	}{
+		{method: http.MethodGet, target: "/", want: "spa"},
+		{method: http.MethodGet, target: "/debug/pprof", want: "debug GET"},
+		{method: http.MethodPost, target: "/debug/x", want: "debug POST"},
+		{method: http.MethodHead, target: "/debug/x", want: "debug HEAD"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if got := rec.Body.String(); got != tt.want {
+			t.Fatalf("%s %s: expected %q, got %q", tt.method, tt.target, tt.want, got)
+		}
+	}
+}
+
+func TestRouter_NotFoundAndMethodNotAllowed(t *testing.T) {
+	r := NewRouter()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/pages/{name}", noop)
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<h1>not found</h1>"))
+	})
+
+	api := r.Group("/api")
+	api.Get("/items", noop)
+	api.Post("/items", noop)
+	api.Method("PURGE", "/items", noop)
+	api.Mount("/files", http.HandlerFunc(noop))
+	v2 := api.Group("/v2")
+	v2.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	v2.Get("/items", noop)
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		body   string
+		allow  string
+	}{
+		{
+			method: http.MethodGet,
+			target: "/missing",
+			status: http.StatusNotFound,
+			body:   "<h1>not found</h1>",
+		},
+		{
+			method: http.MethodGet,
+			target: "/api/missing",
+			status: http.StatusNotFound,
+			body:   "<h1>not found</h1>",
+		},
+		{
+			method: http.MethodDelete,
+			target: "/api/items",
+			status: http.StatusMethodNotAllowed,
+			body:   `{"message":"Method Not Allowed"}`,
+			allow:  "GET, HEAD, POST, PURGE",
+		},
+		{
+			method: http.MethodPost,
+			target: "/api/v2/items",
+			status: http.StatusTeapot,
+			allow:  "GET, HEAD",
+		},
+		{method: http.MethodDelete, target: "/api/files/x", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.body {
+				t.Fatalf("expected body %q, got %q", tt.body, got)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Fatalf("expected Allow %q, got %q", tt.allow, got)
+			}
+		})
+	}
+}
+
+func TestRouter_RouteOptions(t *testing.T) {
+	r := NewRouter()
+	r.Use(markerMiddleware("scope"))
+
+	var (
+		got         Route
+		hasDeadline bool
+	)
+	r.Get(
+		"/users/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			got, _ = RouteFromContext(r.Context())
+			_, hasDeadline = r.Context().Deadline()
+		},
+		WithRouteName("get-user"),
+		WithRouteTags("users"),
+		WithRouteTimeout(time.Second),
+		WithRouteMetadata("permission", "users:read"),
+		WithRouteMiddlewares(markerMiddleware("a"), markerMiddleware("b")),
+	)
+	r.Get("/plain", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	wantOrder := []string{
+		"scope-before", "a-before", "b-before",
+		"b-after", "a-after", "scope-after",
+	}
+	if order := rec.Header().Values("X-Order"); !slices.Equal(order, wantOrder) {
+		t.Fatalf("unexpected middleware order: got %v want %v", order, wantOrder)
+	}
+	if got.Name != "get-user" || got.Pattern != "/users/{id}" ||
+		got.Middlewares != 3 || got.Metadata["permission"] != "users:read" {
+		t.Fatalf("unexpected route in context: %+v", got)
+	}
+	if !hasDeadline {
+		t.Fatal("expected the request's context to have a deadline")
+	}
+
+	if _, ok := RouteFromContext(context.Background()); ok {
+		t.Fatal("expected no route outside of the router")
+	}
+
+	// The returned routes are copies.
+	r.Routes()[1].Metadata["permission"] = "changed"
+	if r.Routes()[1].Metadata["permission"] != "users:read" {
+		t.Fatal("expected routes to be read-only snapshots")
+	}
+}
+
+func TestRouter_URL(t *testing.T) {
+	r := NewRouter()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	api := r.Group("/api")
+	api.Get("/users/{id}", noop, WithRouteName("user"))
+	api.Get("/users/{id}/files/{path...}", noop, WithRouteName("file"))
+	r.Get("/{$}", noop, WithRouteName("home"))
+	r.Get("/unnamed/{id}", noop)
+	// Names keep referring to the route they were first given to.
+	r.Get("/other/{id}", noop, WithRouteName("user"))
+
+	tests := []struct {
+		name    string
+		params  []any
+		want    string
+		wantErr bool
+	}{
+		{name: "user", params: []any{42}, want: "/api/users/42"},
+		{name: "user", params: []any{"a/b c"}, want: "/api/users/a%2Fb%20c"},
+		{
+			name:   "file",
+			params: []any{7, "docs/q1 report.pdf"},
+			want:   "/api/users/7/files/docs/q1%20report.pdf",
+		},
+		{name: "home", want: "/"},
+		{name: "user", wantErr: true},
+		{name: "user", params: []any{""}, wantErr: true},
+		{name: "user", params: []any{1, 2}, wantErr: true},
+		{name: "missing", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.URL(tt.name, tt.params...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRouter_Build(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := NewRouter()
+	r.Get("/users/{id}", noop, WithRouteName("user"))
+	r.Post("/users", noop)
+	// Registering a route again replaces it.
+	r.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	if _, err := r.Build(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the last registration to win, got %d", rec.Code)
+	}
+
+	r = NewRouter()
+	r.Get("/items/{id}", noop)
+	r.Get("/items/{id}", noop)
+	r.Get("/things", noop, WithRouteName("item"))
+	r.Get("/bad/{", noop)
+	api := r.Group("/api")
+	api.Get("/users/{id}", noop, WithRouteName("item"))
+	r.Group("/api/users").Get("/{name}", noop)
+
+	_, err := r.Build()
+	if err == nil {
+		t.Fatal("expected build error")
+	}
+	var routeErr RouteError
+	if !errors.As(err, &routeErr) {
+		t.Fatalf("expected RouteError, got %T", err)
+	}
+	for _, want := range []string{
+		`route GET /bad/{ in scope ""`,
+		`route GET /api/users/{id} in scope "/api": name "item" is already used by /things`,
+		`route GET /api/users/{name} in scope "/api/users": ` +
+			`conflicts with route GET /api/users/{id} in scope "/api"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "registered at") {
+		t.Fatalf("expected no registration locations, got:\n%v", err)
+	}
+}
+
+func TestRouter_ConcurrentServeAndRegister(t *testing.T) {
+	r := NewRouter()
+	r.Get("/first", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Routes are registered while requests are in flight.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for range 50 {
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/first", nil)
+				r.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("expected status 200, got %d", rec.Code)
+					return
+				}
+			}
+		})
+		wg.Go(func() {
+			g := r.Group("/late/" + strconv.Itoa(i))
+			g.Use(markerMiddleware("late"))
+			g.Get("/route", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			})
+		})
+	}
+	wg.Wait()
+
+	// Routes registered after serving has started are picked up.
+	for i := range 8 {
+		rec := httptest.NewRecorder()
+		path := "/late/" + strconv.Itoa(i) + "/route"
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("%s: expected status 202, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestRouter_InvalidRouteWhileServing(t *testing.T) {
+	r := NewRouter()
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/{name}/b", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+	if code := serve("/ok"); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+
+	// It conflicts with "/{name}/b", so the routes can't be rebuilt.
+	r.Get("/a/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	if code := serve("/ok"); code != http.StatusOK {
+		t.Fatalf("expected previous routes to be served, got %d", code)
+	}
+	if code := serve("/a/1"); code != http.StatusNotFound {
+		t.Fatalf("expected conflicting route not to be served, got %d", code)
+	}
+	if _, err := r.Build(); err == nil ||
+		!strings.Contains(err.Error(), "route GET /a/{id}") {
+		t.Fatalf("expected build error of the conflicting route, got %v", err)
+	}
+}
+
+func TestRouter_MiddlewaresCallingRouter(t *testing.T) {
+	r := NewRouter()
+	callback := func(next http.Handler) http.Handler {
+		r.Routes()
+		r.URL("home")
+		return next
+	}
+	r.Use(callback)
+	r.UseAll(callback)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {},
+			WithRouteName("home"),
+			WithRouteMiddlewares(func(next http.Handler) http.Handler {
+				r.Get("/registered", func(w http.ResponseWriter, r *http.Request) {})
+				return callback(next)
+			}),
+		)
+		r.ServeHTTP(
+			httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil),
+		)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deadlock: middleware constructors called back into the router")
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/registered", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+}
@@ -2,10 +2,7 @@ package grape
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"maps"
 	"net/http"
 	"strings"
@@ -14,15 +11,20 @@ import (
 	"github.com/hossein1376/grape/slogger"
 )
 
+var errNotJSON = errors.New("content type is not application/json")
+
 type writeOption struct {
-	status  int
-	data    any
-	headers http.Header
+	status       int
+	data         any
+	headers      http.Header
+	request      *http.Request
+	etag         string
+	strongETag   bool
+	lastModified time.Time
 }
 
 func defaultWriteOptions() *writeOption {
 	headers := make(http.Header)
-	headers.Set("Content-Type", "application/json")
 	headers.Set("Date", time.Now().Format(http.TimeFormat))
 	return &writeOption{
 		status:  http.StatusOK,
@@ -53,33 +55,63 @@ func WithHeaders(headers http.Header) func(*writeOption) {
 // WriteJSON will write back data in json format with the provided status code
 // and headers. It automatically sets content-type and date headers. To override,
 // provide them as headers.
+//
+// If the request is provided via [WithRequest], conditional GET requests are
+// evaluated against the ETag and Last-Modified of the response; replying with
+// 304 status code and no body if the client's copy is still fresh.
 func WriteJSON(ctx context.Context, w http.ResponseWriter, opts ...WriteOpts) {
 	opt := defaultWriteOptions()
 	for _, o := range opts {
 		o(opt)
 	}
-	maps.Copy(w.Header(), opt.headers)
+	write(ctx, w, opt, JSONCodec)
+}
 
-	if opt.data == nil {
-		w.WriteHeader(opt.status)
-		return
+// write encodes the data with the first codec able to, and writes it back.
+// Refer to [WriteJSON] for more details.
+func write(
+	ctx context.Context,
+	w http.ResponseWriter,
+	opt *writeOption,
+	codecs ...Codec,
+) {
+	codec := codecs[0]
+	var body []byte
+	if opt.data != nil {
+		var err error
+		for _, codec = range codecs {
+			if body, err = codec.Marshal(opt.data); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			slogger.Error(ctx, "marshal data", slogger.Err("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 
-	js, err := json.Marshal(opt.data)
-	if err != nil {
-		slogger.Error(ctx, "marshal data", slogger.Err("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
+	if opt.headers.Get("Content-Type") == "" {
+		opt.headers.Set("Content-Type", codec.MediaType())
+	}
+	maps.Copy(w.Header(), opt.headers)
+	if writeNotModified(w, opt, body) {
 		return
 	}
+
 	w.WriteHeader(opt.status)
-	if _, err = w.Write(js); err != nil {
+	if body == nil {
+		return
+	}
+	if _, err := w.Write(body); err != nil {
 		slogger.Error(ctx, "write response", slogger.Err("error", err))
 		return
 	}
 }
 
 type readOptions[T any] struct {
-	maxBodySize int64
+	maxBodySize    int64
+	maxElementSize int64
 }
 
 type ReadOpts[T any] func(*readOptions[T])
@@ -90,6 +122,22 @@ func WithMaxBodySize(size int64) func(*readOptions[any]) {
 	}
 }
 
+// WithMaxTotalSize sets the maximum size of the request's body, in bytes. It is
+// the same as [WithMaxBodySize], but can be used with any type.
+func WithMaxTotalSize[T any](size int64) ReadOpts[T] {
+	return func(o *readOptions[T]) {
+		o.maxBodySize = size
+	}
+}
+
+// WithMaxElementSize sets the maximum size of each element, in bytes, read by
+// [ReadJSONStream]. By default, it is 1MB.
+func WithMaxElementSize[T any](size int64) ReadOpts[T] {
+	return func(o *readOptions[T]) {
+		o.maxElementSize = size
+	}
+}
+
 // ReadJSON will decode incoming json requests. It will return a human-readable
 // error in case of failure. If [T] implements the following method:
 //
@@ -100,62 +148,39 @@ func WithMaxBodySize(size int64) func(*readOptions[any]) {
 func ReadJSON[T any](
 	w http.ResponseWriter, r *http.Request, opts ...ReadOpts[T],
 ) (*T, error) {
-	ct := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Type")))
-	if !strings.HasPrefix(ct, "application/json") {
-		return nil, errors.New("content type is not application/json")
+	if !isJSON(r) {
+		return nil, errNotJSON
 	}
 	opt := &readOptions[T]{maxBodySize: defaultMaxBodySize}
 	for _, o := range opts {
 		o(opt)
 	}
-	r.Body = http.MaxBytesReader(w, r.Body, opt.maxBodySize)
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
 
 	dst := new(T)
-	err := dec.Decode(dst)
-	if err == nil {
-		if err := dec.Decode(&struct{}{}); err != io.EOF {
-			return nil, errors.New("body must only contain a single JSON value")
-		}
-		if v, ok := any(dst).(interface{ Validate() error }); ok {
-			err = v.Validate()
-		}
-		return dst, err
-	}
-
-	var syntaxError *json.SyntaxError
-	var unmarshalTypeError *json.UnmarshalTypeError
-	switch {
-	case errors.Is(err, io.EOF):
-		return nil, errors.New("body must not be empty")
-	case errors.Is(err, io.ErrUnexpectedEOF):
-		return nil, errors.New("body contains badly-formed JSON")
-	case errors.As(err, &syntaxError):
-		return nil, fmt.Errorf(
-			"body contains badly-formed JSON (at character %d)",
-			syntaxError.Offset,
-		)
-	case errors.As(err, &unmarshalTypeError):
-		if unmarshalTypeError.Field != "" {
-			return nil, fmt.Errorf(
-				"body contains incorrect JSON type for field %q",
-				unmarshalTypeError.Field,
-			)
-		}
-		return nil, fmt.Errorf(
-			"body contains incorrect JSON type (at character %d)",
-			unmarshalTypeError.Offset,
-		)
-	case err.Error() == "http: request body too large":
-		return nil, fmt.Errorf(
-			"body must not be larger than %d bytes", opt.maxBodySize,
-		)
-	case strings.HasPrefix(err.Error(), "json: unknown field "):
-		fieldName := strings.Trim(
-			strings.TrimPrefix(err.Error(), "json: unknown field "), "\"")
-		return nil, fmt.Errorf("body contains unknown key %q", fieldName)
-	default:
-		return nil, fmt.Errorf("unable to parse body: %w", err)
+	if err := decodeJSON(w, r, dst, opt.maxBodySize); err != nil {
+		return nil, err
 	}
+	return dst, validate(dst)
+}
+
+func isJSON(r *http.Request) bool {
+	ct := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Type")))
+	return strings.HasPrefix(ct, "application/json")
+}
+
+// validate calls the Validate method of dst, if it is implemented.
+func validate(dst any) error {
+	if v, ok := dst.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// decodeJSON decodes the request's body into dst, translating the decoder's
+// errors into human-readable ones. The content type is not checked.
+func decodeJSON(
+	w http.ResponseWriter, r *http.Request, dst any, maxBodySize int64,
+) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	return JSONCodec.Decode(r.Body, dst)
 }
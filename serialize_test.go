@@ -2,6 +2,7 @@ package grape
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,6 +11,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // --- WriteJSON tests ---
@@ -226,3 +228,127 @@ func TestReadJSON_UnexpectedReadError(t *testing.T) {
 		t.Fatalf("expected error for broken reader, got nil")
 	}
 }
+
+// --- Conditional GET tests ---
+
+func TestWriteJSON_StrongETagNotModified(t *testing.T) {
+	data := Map{"items": []int{1, 2, 3}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	WriteJSON(
+		context.TODO(), rec, WithData(data), WithRequest(req), WithStrongETag(),
+	)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with ETag, got %d %q", rec.Code, etag)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rec = httptest.NewRecorder()
+	Respond(
+		context.TODO(), rec, http.StatusOK, data, WithRequest(req), WithStrongETag(),
+	)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected status 304, got %d", rec.Code)
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+		t.Fatalf("unexpected 304 response: %q %v", rec.Body.String(), rec.Header())
+	}
+
+	// A changed body produces a different ETag.
+	rec = httptest.NewRecorder()
+	Respond(
+		context.TODO(),
+		rec,
+		http.StatusOK,
+		Map{"items": []int{1}},
+		WithRequest(req),
+		WithStrongETag(),
+	)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("expected a fresh response, got %d", rec.Code)
+	}
+}
+
+func TestWriteJSON_ConditionalHeaders(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		method  string
+		status  int
+		headers map[string]string
+		opts    []WriteOpts
+		want    int
+	}{
+		{
+			name:    "caller etag matches",
+			headers: map[string]string{"If-None-Match": `"v1"`},
+			opts:    []WriteOpts{WithETag("v1")},
+			want:    http.StatusNotModified,
+		},
+		{
+			name:    "wildcard",
+			headers: map[string]string{"If-None-Match": "*"},
+			opts:    []WriteOpts{WithETag("v1")},
+			want:    http.StatusNotModified,
+		},
+		{
+			name: "if-none-match takes precedence",
+			headers: map[string]string{
+				"If-None-Match":     `"v0"`,
+				"If-Modified-Since": modified.Format(http.TimeFormat),
+			},
+			opts: []WriteOpts{WithETag("v1"), WithLastModified(modified)},
+			want: http.StatusOK,
+		},
+		{
+			name: "not modified since",
+			headers: map[string]string{
+				"If-Modified-Since": modified.Format(http.TimeFormat),
+			},
+			opts: []WriteOpts{WithLastModified(modified.Add(time.Millisecond))},
+			want: http.StatusNotModified,
+		},
+		{
+			name: "modified since",
+			headers: map[string]string{
+				"If-Modified-Since": modified.Format(http.TimeFormat),
+			},
+			opts: []WriteOpts{WithLastModified(modified.Add(time.Hour))},
+			want: http.StatusOK,
+		},
+		{
+			name:    "unsafe method",
+			method:  http.MethodPost,
+			headers: map[string]string{"If-None-Match": `"v1"`},
+			opts:    []WriteOpts{WithETag("v1")},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "non-200 status",
+			status:  http.StatusCreated,
+			headers: map[string]string{"If-None-Match": `"v1"`},
+			opts:    []WriteOpts{WithETag("v1")},
+			want:    http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := cmp.Or(tt.method, http.MethodGet)
+			req := httptest.NewRequest(method, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			opts := append(tt.opts, WithRequest(req), WithData(Map{"a": 1}))
+			if tt.status != 0 {
+				opts = append(opts, WithStatus(tt.status))
+			}
+			rec := httptest.NewRecorder()
+			WriteJSON(context.TODO(), rec, opts...)
+			if rec.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
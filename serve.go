@@ -0,0 +1,130 @@
+package grape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const defaultDrainTimeout = 30 * time.Second
+
+type serveOption struct {
+	server         *http.Server
+	drainTimeout   time.Duration
+	onShutdown     []func(ctx context.Context) error
+	waitGoroutines bool
+}
+
+type ServeOpts func(*serveOption)
+
+// WithServer sets the [http.Server] to be used. Its Addr and Handler fields are
+// populated by [Router.ServeWithContext] itself.
+func WithServer(server *http.Server) ServeOpts {
+	return func(o *serveOption) {
+		o.server = server
+	}
+}
+
+// WithDrainTimeout sets the maximum duration of the shutdown; including
+// draining the in-flight requests, waiting for goroutines, and running the
+// shutdown hooks. By default, it is 30 seconds.
+func WithDrainTimeout(timeout time.Duration) ServeOpts {
+	return func(o *serveOption) {
+		o.drainTimeout = timeout
+	}
+}
+
+// WithOnShutdown registers a hook to be called after the server has stopped,
+// such as closing database pools. Hooks are called in the order they were
+// registered, and receive a context bounded by the drain timeout.
+func WithOnShutdown(hook func(ctx context.Context) error) ServeOpts {
+	return func(o *serveOption) {
+		o.onShutdown = append(o.onShutdown, hook)
+	}
+}
+
+// WithWaitGoroutines waits for the goroutines spawned by [Go] to finish after
+// the server has stopped, and before the shutdown hooks are called.
+func WithWaitGoroutines() ServeOpts {
+	return func(o *serveOption) {
+		o.waitGoroutines = true
+	}
+}
+
+// ServeWithContext starts the server on the provided address, and gracefully
+// shuts it down once the context is canceled, or a SIGINT or SIGTERM signal is
+// received. It makes no difference on which instance of Router this method is
+// called from.
+//
+// The returned error is nil if the server has shut down cleanly. Otherwise, it
+// reports the startup failure, or all the errors that occurred during the
+// shutdown.
+//
+// Example:
+//
+//	err := r.ServeWithContext(
+//		ctx,
+//		":8000",
+//		grape.WithDrainTimeout(10*time.Second),
+//		grape.WithWaitGoroutines(),
+//		grape.WithOnShutdown(func(ctx context.Context) error {
+//			return db.Close()
+//		}),
+//	)
+func (r *Router) ServeWithContext(
+	ctx context.Context, addr string, opts ...ServeOpts,
+) error {
+	opt := &serveOption{drainTimeout: defaultDrainTimeout}
+	for _, o := range opts {
+		o(opt)
+	}
+	server, err := r.newServer(addr, opt.server)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failure := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			failure <- err
+		}
+	}()
+
+	select {
+	case err := <-failure:
+		return fmt.Errorf("start server: %w", err)
+	case <-ctx.Done():
+	}
+	// Restore the default behaviour, so a second signal terminates the
+	// process immediately.
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(), opt.drainTimeout,
+	)
+	defer cancel()
+
+	var errs []error
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown server: %w", err))
+	}
+	if opt.waitGoroutines {
+		if err := Wait(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("wait for goroutines: %w", err))
+		}
+	}
+	for _, hook := range opt.onShutdown {
+		if err := hook(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+package grape
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServeWithContext_GracefulShutdown(t *testing.T) {
+	r := NewRouter()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var finished atomic.Bool
+	var hooks []string
+	Go(func() {
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.ServeWithContext(
+			ctx,
+			"127.0.0.1:0",
+			WithDrainTimeout(time.Second),
+			WithWaitGoroutines(),
+			WithOnShutdown(func(ctx context.Context) error {
+				hooks = append(hooks, "first")
+				return nil
+			}),
+			WithOnShutdown(func(ctx context.Context) error {
+				hooks = append(hooks, "second")
+				return errors.New("close pool")
+			}),
+		)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "shutdown hook: close pool" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("server did not shut down")
+	}
+	if !finished.Load() {
+		t.Fatalf("expected goroutines to be waited for")
+	}
+	if len(hooks) != 2 || hooks[0] != "first" || hooks[1] != "second" {
+		t.Fatalf("unexpected hooks order: %v", hooks)
+	}
+}
+
+func TestServeWithContext_StartupFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	r := NewRouter()
+	srv := &http.Server{ReadHeaderTimeout: time.Second}
+	err = r.ServeWithContext(
+		context.Background(), ln.Addr().String(), WithServer(srv),
+	)
+	if err == nil {
+		t.Fatalf("expected error when address is in use")
+	}
+	if srv.Handler == nil {
+		t.Fatalf("expected provided server to be used")
+	}
+}
+
+func TestWait_ContextDone(t *testing.T) {
+	release := make(chan struct{})
+	Go(func() { <-release })
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package grape
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSSEClosed is returned when writing to an [SSE] after it is closed.
+var ErrSSEClosed = errors.New("event stream is closed")
+
+type sseOption struct {
+	heartbeat time.Duration
+	retry     time.Duration
+}
+
+type SSEOpts func(*sseOption)
+
+// WithHeartbeat sends a comment to the client on the given interval, keeping
+// the connection alive through proxies that close idle connections.
+func WithHeartbeat(interval time.Duration) SSEOpts {
+	return func(o *sseOption) {
+		o.heartbeat = interval
+	}
+}
+
+// WithRetry tells the client how long to wait before reconnecting, after the
+// connection is lost.
+func WithRetry(retry time.Duration) SSEOpts {
+	return func(o *sseOption) {
+		o.retry = retry
+	}
+}
+
+// SSE writes Server-Sent Events to the client. Its methods are safe to be
+// called concurrently. Create a new instance with [NewSSE].
+type SSE struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	ctx    context.Context
+	lastID string
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewSSE starts an event stream by writing its headers to the client. It
+// returns an error if the [http.ResponseWriter] does not support flushing; in
+// which case nothing is written.
+//
+// The stream ends once the client disconnects, and all further writes return
+// the context's error. Close must be called before the handler returns.
+//
+// Example:
+//
+//	sse, err := grape.NewSSE(w, r, grape.WithHeartbeat(15*time.Second))
+//	if err != nil {
+//		grape.ExtractFromErr(r.Context(), w, err)
+//		return
+//	}
+//	defer sse.Close()
+//
+//	for progress := range job.Progress() {
+//		if err := sse.Send("progress", "", progress); err != nil {
+//			return
+//		}
+//	}
+func NewSSE(
+	w http.ResponseWriter, r *http.Request, opts ...SSEOpts,
+) (*SSE, error) {
+	opt := &sseOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		h.Del("Content-Type")
+		h.Del("Cache-Control")
+		h.Del("X-Accel-Buffering")
+		return nil, fmt.Errorf("event stream: %w", err)
+	}
+
+	s := &SSE{
+		w:      w,
+		rc:     rc,
+		ctx:    r.Context(),
+		lastID: r.Header.Get("Last-Event-ID"),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if opt.retry > 0 {
+		retry := strconv.FormatInt(opt.retry.Milliseconds(), 10)
+		if err := s.write("retry: " + retry + "\n\n"); err != nil {
+			return nil, err
+		}
+	}
+	if opt.heartbeat <= 0 {
+		close(s.done)
+		return s, nil
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(opt.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Comment("heartbeat"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return s, nil
+}
+
+// LastEventID returns the value of the Last-Event-ID header, which is sent by
+// clients when reconnecting. It can be used to resume the stream.
+func (s *SSE) LastEventID() string {
+	return s.lastID
+}
+
+// Done returns a channel that is closed when the client disconnects.
+func (s *SSE) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send writes an event to the client, and flushes it immediately. The data is
+// encoded in JSON format. Both event and id are optional, and must not contain
+// line breaks.
+func (s *SSE) Send(event, id string, data any) error {
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n") {
+		return errors.New("event and id must not contain line breaks")
+	}
+	js, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal data: %w", err)
+	}
+
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	b.WriteString("data: ")
+	b.Write(js)
+	b.WriteString("\n\n")
+	return s.write(b.String())
+}
+
+// Comment writes a comment line to the client, which is ignored by it.
+func (s *SSE) Comment(text string) error {
+	if text == "" {
+		return s.write(":\n\n")
+	}
+	var b strings.Builder
+	for line := range strings.Lines(text) {
+		b.WriteString(": " + strings.TrimRight(line, "\r\n") + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Close stops the heartbeat, and prevents further writes. It does not close
+// the underlying connection, which happens after the handler returns.
+func (s *SSE) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *SSE) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSSEClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
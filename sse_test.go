@@ -0,0 +1,107 @@
+package grape
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSE_SendThroughLogger(t *testing.T) {
+	handler := LoggerMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sse, err := NewSSE(w, r, WithRetry(time.Second))
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			defer sse.Close()
+			if sse.LastEventID() != "41" {
+				t.Errorf("unexpected last event id: %q", sse.LastEventID())
+			}
+			if err := sse.Send("progress", "42", Map{"done": 10}); err != nil {
+				t.Errorf("unexpected send error: %v", err)
+			}
+			if err := sse.Comment("keep\nalive"); err != nil {
+				t.Errorf("unexpected comment error: %v", err)
+			}
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	handler.ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Fatalf("expected response to be flushed through the logger")
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content-type %q", ct)
+	}
+	want := "retry: 1000\n\n" +
+		"id: 42\nevent: progress\ndata: {\"done\":10}\n\n" +
+		": keep\n: alive\n\n"
+	if rec.Body.String() != want {
+		t.Fatalf("unexpected body:\n%q\nwant:\n%q", rec.Body.String(), want)
+	}
+}
+
+func TestSSE_InvalidFieldsAndClose(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	sse, err := NewSSE(rec, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := sse.Send("bad\nevent", "", nil); err == nil {
+		t.Fatalf("expected error for line break in event")
+	}
+	sse.Close()
+	if err := sse.Send("", "", 1); !errors.Is(err, ErrSSEClosed) {
+		t.Fatalf("expected ErrSSEClosed, got %v", err)
+	}
+}
+
+func TestSSE_HeartbeatAndDisconnect(t *testing.T) {
+	stopped := make(chan error, 1)
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sse, err := NewSSE(w, r, WithHeartbeat(10*time.Millisecond))
+			if err != nil {
+				stopped <- err
+				return
+			}
+			defer sse.Close()
+			<-sse.Done()
+			stopped <- sse.Send("", "", "late")
+		}),
+	)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, ": heartbeat") {
+		t.Fatalf("expected heartbeat, got %q (%v)", line, err)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler did not observe client disconnect")
+	}
+}
@@ -0,0 +1,236 @@
+package grape
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hossein1376/grape/errs"
+)
+
+type staticOption struct {
+	cacheControl string
+	listing      bool
+	spa          bool
+}
+
+type StaticOpts func(*staticOption)
+
+// WithCacheControl sets the Cache-Control header of the served files. By
+// default, it is "no-cache"; so clients revalidate their copy on each request,
+// using the ETag and Last-Modified headers.
+func WithCacheControl(value string) StaticOpts {
+	return func(o *staticOption) {
+		o.cacheControl = value
+	}
+}
+
+// WithDirectoryListing lists the content of directories without an index.html
+// file. By default, they are responded with 404 status code.
+func WithDirectoryListing() StaticOpts {
+	return func(o *staticOption) {
+		o.listing = true
+	}
+}
+
+// WithSPAFallback serves the root index.html file for unknown paths, so the
+// client-side router of a single-page app can handle them. Paths with a file
+// extension, such as missing assets, are still responded with 404 status code.
+func WithSPAFallback() StaticOpts {
+	return func(o *staticOption) {
+		o.spa = true
+	}
+}
+
+// Static serves the files of fsys under the given prefix, which is relative to
+// the router's scope. The route is registered with [Router.Get], so it is
+// wrapped by the router's middlewares like any other route.
+//
+// Directories are served by their index.html file. If the client accepts gzip
+// encoding, and a precompressed variant of the file with the ".gz" extension
+// exists, it is served instead. Files have an ETag based on their content, and
+// a Last-Modified header if their modification time is known. Conditional and
+// range requests are handled by [http.ServeContent].
+//
+// Example:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	app, _ := fs.Sub(dist, "dist")
+//	r.Static("/", app, grape.WithSPAFallback())
+func (r *Router) Static(prefix string, fsys fs.FS, opts ...StaticOpts) {
+	opt := &staticOption{cacheControl: "no-cache"}
+	for _, o := range opts {
+		o(opt)
+	}
+	s := &staticServer{fsys: fsys, opt: opt}
+	r.Get(strings.TrimSuffix(prefix, "/")+"/{path...}", s.serve)
+}
+
+type staticServer struct {
+	fsys fs.FS
+	opt  *staticOption
+	// etags caches the ETag of the files, keyed by their name, size and
+	// modification time.
+	etags sync.Map
+}
+
+func (s *staticServer) serve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.PathValue("path")), "/")
+	if name == "" {
+		name = "."
+	}
+	if fs.ValidPath(name) && s.serveName(w, r, name) {
+		return
+	}
+	if s.opt.spa && path.Ext(name) == "" && s.serveName(w, r, "index.html") {
+		return
+	}
+	ExtractFromErr(r.Context(), w, errs.NotFound())
+}
+
+// serveName serves the named file or directory, and reports whether it exists.
+func (s *staticServer) serveName(
+	w http.ResponseWriter, r *http.Request, name string,
+) bool {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() {
+		s.serveFile(w, r, name, info)
+		return true
+	}
+
+	index := path.Join(name, "index.html")
+	if info, err := fs.Stat(s.fsys, index); err == nil && !info.IsDir() {
+		s.serveFile(w, r, index, info)
+		return true
+	}
+	if !s.opt.listing {
+		return false
+	}
+	s.list(w, r, name)
+	return true
+}
+
+func (s *staticServer) serveFile(
+	w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo,
+) {
+	h := w.Header()
+	served := name
+	if gz, err := fs.Stat(s.fsys, name+".gz"); err == nil && !gz.IsDir() {
+		h.Add("Vary", "Accept-Encoding")
+		if negotiateEncoding(r.Header.Get("Accept-Encoding"), "gzip") != "" {
+			served, info = name+".gz", gz
+			h.Set("Content-Encoding", "gzip")
+		}
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		h.Set("Content-Type", ct)
+	} else if served != name {
+		// Otherwise, the type would be sniffed from the compressed content.
+		h.Set("Content-Type", "application/octet-stream")
+	}
+
+	f, err := s.fsys.Open(served)
+	if err != nil {
+		ExtractFromErr(r.Context(), w, fmt.Errorf("open file: %w", err))
+		return
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			ExtractFromErr(r.Context(), w, fmt.Errorf("read file: %w", err))
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	etag, err := s.etag(served, info, content)
+	if err != nil {
+		ExtractFromErr(r.Context(), w, fmt.Errorf("read file: %w", err))
+		return
+	}
+	h.Set("ETag", etag)
+	if s.opt.cacheControl != "" {
+		h.Set("Cache-Control", s.opt.cacheControl)
+	}
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// etag returns the strong ETag of the file's content, which is computed once
+// per version of the file. The content is rewound afterward.
+func (s *staticServer) etag(
+	name string, info fs.FileInfo, content io.ReadSeeker,
+) (string, error) {
+	key := name + "|" + strconv.FormatInt(info.Size(), 10) + "|" +
+		strconv.FormatInt(info.ModTime().UnixNano(), 10)
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	sum := hash.Sum(nil)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	s.etags.Store(key, etag)
+	return etag, nil
+}
+
+// list writes a simple HTML page, linking to the entries of the directory.
+func (s *staticServer) list(
+	w http.ResponseWriter, r *http.Request, name string,
+) {
+	// The links are relative, so they only resolve under a trailing slash.
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		target := path.Base(r.URL.Path) + "/"
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		ExtractFromErr(r.Context(), w, fmt.Errorf("read directory: %w", err))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("<!doctype html>\n<meta charset=\"utf-8\">\n<pre>\n")
+	for _, e := range entries {
+		entry := e.Name()
+		if e.IsDir() {
+			entry += "/"
+		}
+		fmt.Fprintf(
+			&b, "<a href=\"%s\">%s</a>\n",
+			html.EscapeString((&url.URL{Path: entry}).String()),
+			html.EscapeString(entry),
+		)
+	}
+	b.WriteString("</pre>\n")
+
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, b.String())
+}
@@ -0,0 +1,140 @@
+package grape
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var staticFS = fstest.MapFS{
+	"index.html":        {Data: []byte("<h1>home</h1>"), ModTime: time.Unix(1e9, 0)},
+	"app.js":            {Data: []byte("console.log(1)")},
+	"app.js.gz":         {Data: []byte("\x1f\x8bcompressed")},
+	"docs/guide.txt":    {Data: []byte("guide")},
+	"private/notes.txt": {Data: []byte("notes")},
+}
+
+func serveStatic(
+	t *testing.T, target string, header http.Header, opts ...StaticOpts,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	r := NewRouter()
+	var calls int
+	g := r.Group("/assets")
+	g.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			next.ServeHTTP(w, r)
+		})
+	})
+	g.Static("/", staticFS, opts...)
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if calls != 1 && rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the group's middleware to be called once, got %d", calls)
+	}
+	return rec
+}
+
+func TestStatic_ServesFiles(t *testing.T) {
+	rec := serveStatic(t, "/assets/", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "<h1>home</h1>" {
+		t.Fatalf("unexpected response: %d %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected html content type, got %q", ct)
+	}
+	if rec.Header().Get("Last-Modified") == "" || rec.Header().Get("ETag") == "" {
+		t.Fatalf("expected caching headers, got %v", rec.Header())
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("expected cache-control no-cache, got %q", cc)
+	}
+
+	etag := rec.Header().Get("ETag")
+	rec = serveStatic(t, "/assets/index.html", http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected status 304, got %d", rec.Code)
+	}
+}
+
+func TestStatic_Precompressed(t *testing.T) {
+	rec := serveStatic(
+		t, "/assets/app.js", http.Header{"Accept-Encoding": {"br, gzip"}},
+	)
+	if rec.Header().Get("Content-Encoding") != "gzip" ||
+		rec.Body.String() != "\x1f\x8bcompressed" {
+		t.Fatalf("expected gzip variant, got %v %q", rec.Header(), rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Fatalf("expected javascript content type, got %q", ct)
+	}
+
+	rec = serveStatic(t, "/assets/app.js", nil)
+	if rec.Header().Get("Content-Encoding") != "" ||
+		rec.Body.String() != "console.log(1)" {
+		t.Fatalf("expected plain file, got %v %q", rec.Header(), rec.Body.String())
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected Vary header, got %v", rec.Header())
+	}
+
+	rec = serveStatic(
+		t, "/assets/app.js", http.Header{"Accept-Encoding": {"*;q=0, gzip"}},
+	)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected explicit gzip to win over *, got %v", rec.Header())
+	}
+	rec = serveStatic(
+		t, "/assets/app.js", http.Header{"Accept-Encoding": {"gzip;q=0, *"}},
+	)
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected refused gzip not to be served, got %v", rec.Header())
+	}
+}
+
+func TestStatic_Directories(t *testing.T) {
+	rec := serveStatic(t, "/assets/docs/", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 without listing, got %d", rec.Code)
+	}
+
+	rec = serveStatic(t, "/assets/docs", nil, WithDirectoryListing())
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	rec = serveStatic(t, "/assets/docs/", nil, WithDirectoryListing())
+	if rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `<a href="guide.txt">`) {
+		t.Fatalf("unexpected listing: %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStatic_SPAFallback(t *testing.T) {
+	tests := []struct {
+		target string
+		status int
+	}{
+		{target: "/assets/users/42", status: http.StatusOK},
+		{target: "/assets/missing.js", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := serveStatic(t, tt.target, nil, WithSPAFallback())
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK && rec.Body.String() != "<h1>home</h1>" {
+				t.Fatalf("expected index.html, got %q", rec.Body.String())
+			}
+		})
+	}
+}
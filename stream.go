@@ -0,0 +1,185 @@
+package grape
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultFlushEvery    = 100
+	defaultFlushInterval = time.Second
+)
+
+type streamOption struct {
+	flushEvery    int
+	flushInterval time.Duration
+}
+
+type StreamOpts func(*streamOption)
+
+// WithFlushEvery flushes the response after the given number of items. By
+// default, it is 100 items.
+func WithFlushEvery(items int) StreamOpts {
+	return func(o *streamOption) {
+		o.flushEvery = items
+	}
+}
+
+// WithFlushInterval flushes the response if the given duration has passed
+// since the last flush, checked after each item. By default, it is one second.
+func WithFlushInterval(interval time.Duration) StreamOpts {
+	return func(o *streamOption) {
+		o.flushInterval = interval
+	}
+}
+
+// WriteNDJSON writes the items as newline-delimited JSON, encoding each one as
+// soon as it is produced; so the whole response is never held in memory. The
+// response is flushed periodically, as configured by [WithFlushEvery] and
+// [WithFlushInterval].
+//
+// The response is written with 200 status code, and the Content-Type header is
+// set to application/x-ndjson, unless it is already set. Other headers must be
+// set before calling it.
+//
+// Writing stops as soon as the context is canceled, returning its error. Since
+// the header is already written by then, errors can only be logged.
+//
+// Example:
+//
+//	users := db.IterUsers(ctx)
+//	if err := grape.WriteNDJSON(ctx, w, users); err != nil {
+//		slogger.Error(ctx, "export users", slogger.Err("error", err))
+//	}
+func WriteNDJSON[T any](
+	ctx context.Context, w http.ResponseWriter, seq iter.Seq[T],
+	opts ...StreamOpts,
+) error {
+	return writeStream(ctx, w, seq, false, opts)
+}
+
+// WriteJSONArray writes the items as a single JSON array, encoding each one as
+// soon as it is produced. It behaves the same as [WriteNDJSON], except for the
+// format, and the Content-Type header which is set to application/json.
+//
+// If writing stops early, the array is left unterminated; so clients can
+// detect the response is incomplete.
+func WriteJSONArray[T any](
+	ctx context.Context, w http.ResponseWriter, seq iter.Seq[T],
+	opts ...StreamOpts,
+) error {
+	return writeStream(ctx, w, seq, true, opts)
+}
+
+// FromChan returns an iterator over the values received from the channel. It
+// stops once the channel is closed, or the context is canceled.
+func FromChan[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeStream[T any](
+	ctx context.Context,
+	w http.ResponseWriter,
+	seq iter.Seq[T],
+	array bool,
+	opts []StreamOpts,
+) error {
+	opt := &streamOption{
+		flushEvery:    defaultFlushEvery,
+		flushInterval: defaultFlushInterval,
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		if array {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	bw := bufio.NewWriter(w)
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil &&
+			!errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	// Whatever is encoded so far is sent, even if writing stops early.
+	defer bw.Flush()
+
+	if array {
+		if err := bw.WriteByte('['); err != nil {
+			return err
+		}
+	}
+	var i, pending int
+	lastFlush := time.Now()
+	for item := range seq {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		js, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("marshal item %d: %w", i, err)
+		}
+		// In arrays, a comma goes before all but the first item; while in
+		// NDJSON, a line break terminates every item.
+		if array && i > 0 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if !array {
+			js = append(js, '\n')
+		}
+		if _, err := bw.Write(js); err != nil {
+			return err
+		}
+		i++
+		pending++
+
+		if pending >= opt.flushEvery ||
+			time.Since(lastFlush) >= opt.flushInterval {
+			if err := flush(); err != nil {
+				return err
+			}
+			pending, lastFlush = 0, time.Now()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if array {
+		if err := bw.WriteByte(']'); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
@@ -0,0 +1,100 @@
+package grape
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := WriteNDJSON(
+		context.TODO(), rec, slices.Values([]Map{{"id": 1}, {"id": 2}}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("expected content-type application/x-ndjson, got %q", ct)
+	}
+	if got, want := rec.Body.String(), "{\"id\":1}\n{\"id\":2}\n"; got != want {
+		t.Fatalf("expected body %q, got %q", want, got)
+	}
+}
+
+func TestWriteJSONArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []int
+		want  string
+	}{
+		{name: "empty", items: nil, want: "[]"},
+		{name: "single", items: []int{1}, want: "[1]"},
+		{name: "multiple", items: []int{1, 2, 3}, want: "[1,2,3]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			err := WriteJSONArray(
+				context.TODO(), rec, slices.Values(tt.items), WithFlushEvery(1),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Fatalf("expected body %q, got %q", tt.want, got)
+			}
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Fatalf("invalid JSON: %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWriteJSONArray_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+
+	var produced int
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			produced++
+			if i == 2 {
+				cancel()
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	err := WriteJSONArray(ctx, rec, seq)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if produced != 3 {
+		t.Fatalf("expected 3 items to be produced, got %d", produced)
+	}
+	if got := rec.Body.String(); got != "[0,1" {
+		t.Fatalf("expected unterminated array, got %q", got)
+	}
+}
+
+func TestFromChan(t *testing.T) {
+	ch := make(chan string, 2)
+	ch <- "a"
+	ch <- "b"
+	close(ch)
+	rec := httptest.NewRecorder()
+
+	if err := WriteNDJSON(context.TODO(), rec, FromChan(context.TODO(), ch)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := rec.Body.String(), "\"a\"\n\"b\"\n"; got != want {
+		t.Fatalf("expected body %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,125 @@
+package grape
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseWriter wraps an [http.ResponseWriter], recording the status code, the
+// number of body bytes written, and the time to first byte of the response.
+//
+// Unlike embedding the writer in a struct, it keeps the optional interfaces of
+// the underlying writer, namely [http.Flusher], [http.Hijacker] and
+// [io.ReaderFrom]. Other features, such as deadlines, are available through
+// [http.ResponseController], which calls the Unwrap method.
+type ResponseWriter struct {
+	http.ResponseWriter
+	start     time.Time
+	status    int
+	written   int64
+	firstByte time.Duration
+}
+
+// NewResponseWriter wraps the given writer. If it is already a
+// [ResponseWriter], it is returned as is; allowing middlewares to share the
+// recorded values.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w, start: time.Now()}
+}
+
+// Status returns the status code of the response, or zero if the header has
+// not been written yet.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// BytesWritten returns the number of bytes written to the response body.
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.written
+}
+
+// TimeToFirstByte returns the duration between wrapping the writer and writing
+// the response header, or zero if it has not been written yet.
+func (w *ResponseWriter) TimeToFirstByte() time.Duration {
+	return w.firstByte
+}
+
+// WriteHeader records the status code, and then writes the header. Only the
+// first final status code is recorded; informational ones are passed through.
+func (w *ResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 && (statusCode >= 200 || statusCode == 101) {
+		w.markWritten(statusCode)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes the data to the response body, writing the header with 200
+// status code, if it has not been written yet.
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// ReadFrom implements [io.ReaderFrom]. If the underlying writer implements it
+// too, it is used to enable optimizations such as sendfile.
+func (w *ResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	rf, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		// Hide the ReadFrom method from io.Copy, so it doesn't loop.
+		return io.Copy(writerOnly{w}, src)
+	}
+	n, err := rf.ReadFrom(src)
+	w.written += n
+	return n, err
+}
+
+// Flush implements [http.Flusher].
+func (w *ResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError flushes the underlying writer, and reports whether it supports
+// flushing. It is preferred over Flush by [http.ResponseController].
+func (w *ResponseWriter) FlushError() error {
+	if w.status == 0 {
+		w.markWritten(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements [http.Hijacker]. It returns [http.ErrNotSupported] if the
+// underlying writer can't be hijacked.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.markWritten(http.StatusSwitchingProtocols)
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer, for [http.ResponseController].
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *ResponseWriter) markWritten(statusCode int) {
+	w.status = statusCode
+	w.firstByte = time.Since(w.start)
+}
+
+type writerOnly struct {
+	io.Writer
+}
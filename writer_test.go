@@ -0,0 +1,89 @@
+package grape
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseWriter_Records(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+	if NewResponseWriter(rw) != rw {
+		t.Fatalf("expected an existing ResponseWriter to be reused")
+	}
+
+	rw.Write([]byte("hello "))
+	n, err := rw.ReadFrom(strings.NewReader("world"))
+	if err != nil || n != 5 {
+		t.Fatalf("unexpected ReadFrom result: %d, %v", n, err)
+	}
+
+	if rw.Status() != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rw.Status())
+	}
+	if rw.BytesWritten() != 11 {
+		t.Fatalf("expected 11 bytes written, got %d", rw.BytesWritten())
+	}
+	if rw.TimeToFirstByte() <= 0 {
+		t.Fatalf("expected time to first byte to be recorded")
+	}
+	if rec.Body.String() != "hello world" {
+		t.Fatalf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestResponseWriter_PreservesInterfaces(t *testing.T) {
+	var rw http.ResponseWriter = NewResponseWriter(httptest.NewRecorder())
+	if _, ok := rw.(http.Flusher); !ok {
+		t.Errorf("expected http.Flusher to be implemented")
+	}
+	if _, ok := rw.(http.Hijacker); !ok {
+		t.Errorf("expected http.Hijacker to be implemented")
+	}
+	if _, ok := rw.(io.ReaderFrom); !ok {
+		t.Errorf("expected io.ReaderFrom to be implemented")
+	}
+
+	// The recorder can't be hijacked, and that must be reported.
+	if _, _, err := rw.(http.Hijacker).Hijack(); err == nil {
+		t.Errorf("expected hijack error for a recorder")
+	}
+}
+
+// Test that a connection can be hijacked, and a deadline can be set through
+// http.ResponseController, once the LoggerMiddleware wraps the writer.
+func TestResponseWriter_HijackThroughLogger(t *testing.T) {
+	handler := LoggerMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rc := http.NewResponseController(w)
+			if err := rc.SetWriteDeadline(time.Now().Add(time.Second)); err != nil {
+				t.Errorf("unexpected deadline error: %v", err)
+			}
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("unexpected hijack error: %v", err)
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\n\r\nhijacked")
+			buf.Flush()
+		}),
+	)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(bufio.NewReader(resp.Body))
+	if string(body) != "hijacked" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,216 @@
+package ws
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// maxControlPayload is the maximum payload length of control frames.
+const maxControlPayload = 125
+
+var (
+	errProtocol    = errors.New("protocol error")
+	errInvalidUTF8 = errors.New("invalid UTF-8 in text message")
+)
+
+// readMessage reads frames until a whole data message is received. Control
+// frames in between are handled as they arrive.
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var typ MessageType
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame(c.opt.readLimit - int64(len(msg)))
+		if err != nil {
+			return 0, nil, err
+		}
+		c.lastSeen.Store(time.Now().UnixNano())
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, parseClose(payload)
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, fmt.Errorf(
+					"%w: new message before the previous one is finished",
+					errProtocol,
+				)
+			}
+			typ = MessageType(op)
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, fmt.Errorf(
+					"%w: continuation without a message", errProtocol,
+				)
+			}
+		}
+
+		msg = append(msg, payload...)
+		if !fin {
+			continue
+		}
+		if typ == Text && !utf8.Valid(msg) {
+			return 0, nil, errInvalidUTF8
+		}
+		return typ, msg, nil
+	}
+}
+
+// readFrame reads a single frame, whose payload must not be larger than
+// limit. The payload is unmasked.
+func (c *Conn) readFrame(limit int64) (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	op := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7F)
+
+	switch {
+	case header[0]&0x70 != 0:
+		return false, 0, nil, fmt.Errorf(
+			"%w: reserved bits are set", errProtocol,
+		)
+	case !masked:
+		return false, 0, nil, fmt.Errorf(
+			"%w: client frames must be masked", errProtocol,
+		)
+	case op > opBinary && op < opClose || op > opPong:
+		return false, 0, nil, fmt.Errorf(
+			"%w: unknown opcode %d", errProtocol, op,
+		)
+	case op >= opClose && (!fin || length > maxControlPayload):
+		return false, 0, nil, fmt.Errorf(
+			"%w: invalid control frame", errProtocol,
+		)
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		u := binary.BigEndian.Uint64(ext[:])
+		if u>>63 != 0 {
+			return false, 0, nil, fmt.Errorf(
+				"%w: invalid payload length", errProtocol,
+			)
+		}
+		length = int64(u)
+	}
+	if op < opClose && length > limit {
+		return false, 0, nil, ErrReadLimit
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes a single frame, applying the write timeout. Only close
+// frames may be written once the connection is being closed.
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	if op >= opClose && len(payload) > maxControlPayload {
+		return fmt.Errorf(
+			"control payload must not be longer than %d bytes",
+			maxControlPayload,
+		)
+	}
+	if op != opClose && c.closed.Load() {
+		return ErrClosed
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.opt.writeTimeout > 0 {
+		err := c.conn.SetWriteDeadline(time.Now().Add(c.opt.writeTimeout))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Server frames are never masked.
+	frame := make([]byte, 0, 10+len(payload))
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// closePayload builds the payload of a close frame. The reason is truncated to
+// fit in a control frame.
+func closePayload(code int, reason string) []byte {
+	if code == CloseNoStatus || code == CloseAbnormal {
+		return nil
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > maxControlPayload-2 {
+		reason = strings.ToValidUTF8(reason[:maxControlPayload-2], "")
+	}
+	return append(payload, reason...)
+}
+
+// parseClose parses the payload of a close frame received from the client.
+func parseClose(payload []byte) error {
+	switch {
+	case len(payload) == 0:
+		return &CloseError{Code: CloseNoStatus}
+	case len(payload) == 1:
+		return fmt.Errorf("%w: invalid close payload", errProtocol)
+	case !utf8.Valid(payload[2:]):
+		return errInvalidUTF8
+	}
+	return &CloseError{
+		Code:   int(binary.BigEndian.Uint16(payload)),
+		Reason: string(payload[2:]),
+	}
+}
@@ -0,0 +1,443 @@
+// Package ws implements the server side of the WebSocket protocol, as defined
+// in RFC 6455, on top of [http.Hijacker]. Connections are created by [Upgrade],
+// and exchange text and binary messages; with optional keepalive pings.
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hossein1376/grape"
+	"github.com/hossein1376/grape/errs"
+)
+
+const defaultReadLimit = 1_048_576 // 1mb
+
+// acceptGUID is appended to the client's key to compute the accept key.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MessageType is the type of data messages.
+type MessageType int
+
+const (
+	Text   MessageType = 1
+	Binary MessageType = 2
+)
+
+// Close codes, as defined in RFC 6455 section 7.4.1.
+const (
+	CloseNormalClosure   = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+var (
+	// ErrClosed is returned when using a connection after it is closed.
+	ErrClosed = errors.New("connection is closed")
+	// ErrReadLimit is returned when a message is larger than the read limit.
+	ErrReadLimit = errors.New("message exceeds the read limit")
+)
+
+// CloseError is returned by the read methods once the peer closes the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("connection closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("connection closed with code %d: %s", e.Code, e.Reason)
+}
+
+type option struct {
+	readLimit    int64
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	pingInterval time.Duration
+	subprotocols []string
+	checkOrigin  func(r *http.Request) bool
+}
+
+type Options func(*option)
+
+// WithReadLimit sets the maximum size of incoming messages, in bytes. Larger
+// messages close the connection with [CloseMessageTooBig]. By default, it is
+// 1MB.
+func WithReadLimit(size int64) Options {
+	return func(o *option) {
+		o.readLimit = size
+	}
+}
+
+// WithReadTimeout sets how long each read may wait for the next message. By
+// default, reads wait indefinitely.
+func WithReadTimeout(timeout time.Duration) Options {
+	return func(o *option) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets how long writing each message may take. By default,
+// writes wait indefinitely.
+func WithWriteTimeout(timeout time.Duration) Options {
+	return func(o *option) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithPingInterval sends a ping to the client on the given interval. If
+// nothing is received from the client for two intervals, the connection is
+// closed. Pongs are only processed while reading, so the connection must be
+// read continuously.
+func WithPingInterval(interval time.Duration) Options {
+	return func(o *option) {
+		o.pingInterval = interval
+	}
+}
+
+// WithSubprotocols sets the supported subprotocols, in order of preference.
+// The first one requested by the client is selected.
+func WithSubprotocols(subprotocols ...string) Options {
+	return func(o *option) {
+		o.subprotocols = subprotocols
+	}
+}
+
+// WithOriginCheck sets a predicate to decide whether the request's origin is
+// allowed. By default, only requests without an Origin header, or with one
+// matching the Host header, are allowed.
+func WithOriginCheck(check func(r *http.Request) bool) Options {
+	return func(o *option) {
+		o.checkOrigin = check
+	}
+}
+
+// Conn is a WebSocket connection. A single goroutine may read from it at a
+// time, while writes are safe to be called concurrently.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	opt         *option
+	subprotocol string
+
+	wmu      sync.Mutex
+	closed   atomic.Bool
+	lastSeen atomic.Int64
+	readErr  error
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Upgrade performs the opening handshake, and takes over the connection. On
+// failure, an error is responded via [grape.ExtractFromErr] and returned; in
+// which case the handler must not write anything else.
+//
+// Example:
+//
+//	r.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+//		conn, err := ws.Upgrade(w, r, ws.WithPingInterval(30*time.Second))
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close(ws.CloseNormalClosure, "")
+//
+//		for {
+//			typ, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			if err := conn.WriteMessage(typ, msg); err != nil {
+//				return
+//			}
+//		}
+//	})
+func Upgrade(
+	w http.ResponseWriter, r *http.Request, opts ...Options,
+) (*Conn, error) {
+	opt := &option{readLimit: defaultReadLimit, checkOrigin: sameOrigin}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	ctx := r.Context()
+	key, err := checkHandshake(r)
+	if err != nil {
+		if r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+		}
+		grape.ExtractFromErr(ctx, w, err)
+		return nil, err
+	}
+	if !opt.checkOrigin(r) {
+		err := errs.Forbidden(errs.WithMsg("origin is not allowed"))
+		grape.ExtractFromErr(ctx, w, err)
+		return nil, err
+	}
+	subprotocol := selectSubprotocol(r, opt.subprotocols)
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		err = fmt.Errorf("hijack connection: %w", err)
+		grape.ExtractFromErr(ctx, w, err)
+		return nil, err
+	}
+	// Clear the deadlines which might have been set by the server.
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	b.WriteString("Upgrade: websocket\r\n")
+	b.WriteString("Connection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	b.WriteString("\r\n")
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &Conn{
+		conn:        conn,
+		br:          brw.Reader,
+		opt:         opt,
+		subprotocol: subprotocol,
+		stop:        make(chan struct{}),
+	}
+	c.lastSeen.Store(time.Now().UnixNano())
+	if opt.pingInterval > 0 {
+		go c.keepalive()
+	}
+	return c, nil
+}
+
+// Subprotocol returns the subprotocol selected during the handshake, or an
+// empty string if there is none.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the network address of the client.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline of the current and future reads. It
+// overrides the timeout set by [WithReadTimeout] until the next read.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline of the current and future writes. It
+// overrides the timeout set by [WithWriteTimeout] until the next write.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage reads the next data message, answering pings and handling close
+// frames in the meantime. Once it returns an error, the connection is no
+// longer usable, and all further reads return the same error. If the client
+// closes the connection, the error is a [*CloseError].
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	if c.opt.readTimeout > 0 {
+		err := c.conn.SetReadDeadline(time.Now().Add(c.opt.readTimeout))
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+	}
+	typ, msg, err := c.readMessage()
+	if err != nil {
+		return 0, nil, c.fail(err)
+	}
+	return typ, msg, nil
+}
+
+// ReadJSON reads the next message, and decodes it into v via
+// [grape.JSONCodec]. Decoding errors leave the connection usable.
+func (c *Conn) ReadJSON(v any) error {
+	_, msg, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return grape.JSONCodec.Decode(bytes.NewReader(msg), v)
+}
+
+// WriteMessage writes a data message in a single frame.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if typ != Text && typ != Binary {
+		return fmt.Errorf("invalid message type %d", typ)
+	}
+	return c.writeFrame(byte(typ), data)
+}
+
+// WriteJSON encodes v via [grape.JSONCodec], and writes it as a text message.
+func (c *Conn) WriteJSON(v any) error {
+	data, err := grape.JSONCodec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	return c.WriteMessage(Text, data)
+}
+
+// Ping sends a ping with the given payload, which must not be longer than 125
+// bytes. The client's pong is handled while reading.
+func (c *Conn) Ping(data []byte) error {
+	return c.writeFrame(opPing, data)
+}
+
+// Close sends a close frame with the given code and reason, and then closes
+// the underlying connection. It is safe to be called multiple times.
+func (c *Conn) Close(code int, reason string) error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	err := c.writeFrame(opClose, closePayload(code, reason))
+	return errors.Join(err, c.conn.Close())
+}
+
+// fail records the read error, and closes the connection if it is broken.
+func (c *Conn) fail(err error) error {
+	c.readErr = err
+	var closeErr *CloseError
+	switch {
+	case errors.As(err, &closeErr):
+		c.Close(closeErr.Code, "")
+	case errors.Is(err, ErrReadLimit):
+		c.Close(CloseMessageTooBig, "")
+	case errors.Is(err, errProtocol):
+		c.Close(CloseProtocolError, "")
+	case errors.Is(err, errInvalidUTF8):
+		c.Close(CloseInvalidPayload, "")
+	default:
+		if c.closed.Swap(true) {
+			break
+		}
+		c.stopOnce.Do(func() { close(c.stop) })
+		c.conn.Close()
+	}
+	return err
+}
+
+// keepalive pings the client on the configured interval, and closes the
+// connection if it stops responding.
+func (c *Conn) keepalive() {
+	ticker := time.NewTicker(c.opt.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			idle := time.Since(time.Unix(0, c.lastSeen.Load()))
+			if idle > 2*c.opt.pingInterval {
+				c.Close(CloseGoingAway, "ping timeout")
+				return
+			}
+			if err := c.Ping(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// checkHandshake validates the opening handshake, and returns the client's key.
+func checkHandshake(r *http.Request) (string, error) {
+	switch {
+	case r.Method != http.MethodGet:
+		return "", errs.BadRequest(errs.WithMsg("method must be GET"))
+	case !headerContains(r.Header, "Connection", "upgrade"),
+		!headerContains(r.Header, "Upgrade", "websocket"):
+		return "", errs.BadRequest(
+			errs.WithMsg("request is not a websocket handshake"),
+		)
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		return "", errs.New(
+			http.StatusUpgradeRequired,
+			errs.WithMsg("websocket version must be 13"),
+		)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil ||
+		len(decoded) != 16 {
+		return "", errs.BadRequest(
+			errs.WithMsg("invalid Sec-WebSocket-Key header"),
+		)
+	}
+	return key, nil
+}
+
+// headerContains reports whether the comma-separated header has the token,
+// case-insensitively.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for part := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	var requested []string
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for part := range strings.SplitSeq(v, ",") {
+			requested = append(requested, strings.TrimSpace(part))
+		}
+	}
+	for _, s := range supported {
+		if slices.Contains(requested, s) {
+			return s
+		}
+	}
+	return ""
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,313 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient is a minimal client, speaking just enough of the protocol to
+// exercise the server.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dial(
+	t *testing.T, handler http.HandlerFunc, header http.Header,
+) (*testClient, *http.Response) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	return &testClient{t: t, conn: conn, br: br}, resp
+}
+
+func (c *testClient) send(fin bool, op byte, payload []byte) {
+	c.t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatalf("write frame: %v", err)
+	}
+}
+
+func (c *testClient) recv() (byte, []byte) {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		c.t.Fatalf("read frame: %v", err)
+	}
+	if header[1]&0x80 != 0 {
+		c.t.Fatal("server frames must not be masked")
+	}
+	length := int(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatalf("read payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func (c *testClient) expectClose(code int) {
+	c.t.Helper()
+	op, payload := c.recv()
+	if op != opClose || len(payload) < 2 {
+		c.t.Fatalf("expected close frame, got opcode %d", op)
+	}
+	if got := int(binary.BigEndian.Uint16(payload)); got != code {
+		c.t.Fatalf("expected close code %d, got %d", code, got)
+	}
+}
+
+func echo(opts ...Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, opts...)
+		if err != nil {
+			return
+		}
+		defer conn.Close(CloseNormalClosure, "")
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(typ, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestUpgrade_Handshake(t *testing.T) {
+	_, resp := dial(
+		t,
+		echo(WithSubprotocols("v2", "v1")),
+		http.Header{"Sec-Websocket-Protocol": {"v1, v2"}},
+	)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status 101, got %d", resp.StatusCode)
+	}
+	// The example of RFC 6455 section 1.3.
+	want := "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != want {
+		t.Fatalf("expected accept key %q, got %q", want, got)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != "v2" {
+		t.Fatalf("expected subprotocol v2, got %q", got)
+	}
+}
+
+func TestUpgrade_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{
+			name:   "version",
+			header: http.Header{"Sec-Websocket-Version": {"8"}},
+			status: http.StatusUpgradeRequired,
+		},
+		{
+			name:   "key",
+			header: http.Header{"Sec-Websocket-Key": {"short"}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "origin",
+			header: http.Header{"Origin": {"https://evil.example"}},
+			status: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := dial(t, echo(), tt.header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestConn_Echo(t *testing.T) {
+	c, _ := dial(t, echo(), nil)
+
+	c.send(true, opText, []byte("hello"))
+	if op, msg := c.recv(); op != opText || string(msg) != "hello" {
+		t.Fatalf("unexpected message: %d %q", op, msg)
+	}
+
+	// A fragmented binary message, with a ping in between.
+	large := bytes.Repeat([]byte{1}, 70_000)
+	c.send(false, opBinary, large[:40_000])
+	c.send(true, opPing, []byte("p"))
+	c.send(true, opContinuation, large[40_000:])
+	if op, msg := c.recv(); op != opPong || string(msg) != "p" {
+		t.Fatalf("expected pong, got %d %q", op, msg)
+	}
+	if op, msg := c.recv(); op != opBinary || !bytes.Equal(msg, large) {
+		t.Fatalf("unexpected message: %d of %d bytes", op, len(msg))
+	}
+
+	c.send(true, opClose, append([]byte{0x03, 0xE8}, "bye"...))
+	c.expectClose(CloseNormalClosure)
+}
+
+func TestConn_ProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Options
+		send func(c *testClient)
+		code int
+	}{
+		{
+			name: "too big",
+			opts: []Options{WithReadLimit(10)},
+			send: func(c *testClient) {
+				c.send(true, opText, []byte(strings.Repeat("a", 11)))
+			},
+			code: CloseMessageTooBig,
+		},
+		{
+			name: "invalid utf8",
+			send: func(c *testClient) {
+				c.send(true, opText, []byte{0xff, 0xfe})
+			},
+			code: CloseInvalidPayload,
+		},
+		{
+			name: "orphan continuation",
+			send: func(c *testClient) {
+				c.send(true, opContinuation, []byte("a"))
+			},
+			code: CloseProtocolError,
+		},
+		{
+			name: "unmasked",
+			send: func(c *testClient) {
+				c.conn.Write([]byte{0x81, 0x01, 'a'})
+			},
+			code: CloseProtocolError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := dial(t, echo(tt.opts...), nil)
+			tt.send(c)
+			c.expectClose(tt.code)
+		})
+	}
+}
+
+func TestConn_JSON(t *testing.T) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	done := make(chan error, 1)
+	c, _ := dial(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close(CloseNormalClosure, "")
+
+		var m message
+		if err := conn.ReadJSON(&m); err == nil {
+			done <- errors.New("expected decoding error")
+			return
+		}
+		if err := conn.ReadJSON(&m); err != nil {
+			done <- err
+			return
+		}
+		done <- conn.WriteJSON(message{Text: m.Text + "!"})
+	}, nil)
+
+	c.send(true, opText, []byte(`{"unknown":1}`))
+	c.send(true, opText, []byte(`{"text":"hi"}`))
+	if op, msg := c.recv(); op != opText || string(msg) != `{"text":"hi!"}` {
+		t.Fatalf("unexpected message: %d %q", op, msg)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConn_PingTimeout(t *testing.T) {
+	c, _ := dial(t, echo(WithPingInterval(20*time.Millisecond)), nil)
+
+	// The client never answers the pings.
+	for {
+		op, _ := c.recv()
+		if op == opClose {
+			return
+		}
+		if op != opPing {
+			t.Fatalf("expected ping or close, got opcode %d", op)
+		}
+	}
+}